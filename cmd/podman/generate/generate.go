@@ -0,0 +1,20 @@
+// Package generate implements the `podman generate` family of commands,
+// which render existing podman state into other formats (systemd units,
+// Kubernetes manifests).
+package generate
+
+import (
+	"github.com/containers/podman/v3/cmd/podman/registry"
+	"github.com/spf13/cobra"
+)
+
+var GenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate structured data based on containers, pods or volumes",
+}
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: GenerateCmd,
+	})
+}
@@ -0,0 +1,41 @@
+package generate
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/containers/podman/v3/cmd/podman/registry"
+	"github.com/containers/podman/v3/pkg/domain/entities"
+	"github.com/spf13/cobra"
+)
+
+var (
+	kubeOptions entities.GenerateKubeOptions
+	kubeCmd     = &cobra.Command{
+		Use:   "kube [options] POD",
+		Short: "Generate Kubernetes YAML from a pod",
+		Long:  "Generate Kubernetes YAML (v1 specification) from a podman pod, reversing what `podman play kube` applied.",
+		RunE:  kube,
+		Args:  cobra.ExactArgs(1),
+	}
+)
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: kubeCmd,
+		Parent:  GenerateCmd,
+	})
+
+	flags := kubeCmd.Flags()
+	flags.BoolVarP(&kubeOptions.Service, "service", "s", false, "Has no effect: no Kubernetes service object is generated")
+}
+
+func kube(cmd *cobra.Command, args []string) error {
+	report, err := registry.ContainerEngine().GenerateKube(context.Background(), args[0], kubeOptions)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(os.Stdout, report.Reader)
+	return err
+}
@@ -0,0 +1,71 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/containers/podman/v3/cmd/podman/registry"
+	"github.com/containers/podman/v3/pkg/domain/entities"
+	"github.com/spf13/cobra"
+)
+
+var (
+	systemdOptions entities.GenerateSystemdOptions
+	systemdFormat  string
+	systemdCmd     = &cobra.Command{
+		Use:   "systemd [options] CONTAINER",
+		Short: "Generate a systemd unit file for a container",
+		Long:  "Generate a systemd unit file (or files, with --socket-activated) for running an existing container under systemd.",
+		RunE:  systemd,
+		Args:  cobra.ExactArgs(1),
+	}
+)
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: systemdCmd,
+		Parent:  GenerateCmd,
+	})
+
+	flags := systemdCmd.Flags()
+	flags.BoolVarP(&systemdOptions.Name, "name", "n", false, "Use the container's name instead of its ID in the unit")
+	flags.BoolVar(&systemdOptions.New, "new", false, "Re-create the container with `podman run` instead of starting an existing one")
+	flags.BoolVar(&systemdOptions.NoHeader, "no-header", false, "Skip the explanatory comment header in the generated unit")
+	flags.StringVar(&systemdOptions.ContainerPrefix, "container-prefix", "container", "Prefix for the container unit's service name")
+	flags.StringVar(&systemdOptions.PodPrefix, "pod-prefix", "pod", "Prefix for the pod unit's service name")
+	flags.StringVar(&systemdOptions.Separator, "separator", "-", "Separator between the unit name prefix and the container/pod name")
+	flags.StringArrayVar(&systemdOptions.Requires, "requires", nil, "Systemd unit(s) this unit requires (may be specified multiple times)")
+	flags.StringArrayVar(&systemdOptions.Wants, "wants", nil, "Systemd unit(s) this unit wants (may be specified multiple times)")
+	flags.StringArrayVar(&systemdOptions.After, "after", nil, "Systemd unit(s) this unit starts after (may be specified multiple times)")
+	flags.StringArrayVar(&systemdOptions.Before, "before", nil, "Systemd unit(s) this unit starts before (may be specified multiple times)")
+	flags.StringArrayVar(&systemdOptions.Conflicts, "conflicts", nil, "Systemd unit(s) this unit conflicts with (may be specified multiple times)")
+	flags.StringVar(&systemdOptions.SdNotifyMode, "sdnotify", "container", `Type of notify sent to systemd ("conmon", "container", "healthy", or "ignore")`)
+	flags.BoolVar(&systemdOptions.SocketActivation, "socket-activated", false, "Also generate a .socket unit that socket-activates the service (requires --new and --listen)")
+	flags.StringArrayVar(&systemdOptions.ListenStreams, "listen", nil, "Address to listen on for socket activation, e.g. 127.0.0.1:8080 (may be specified multiple times)")
+	flags.StringArrayVar(&systemdOptions.ListenDatagrams, "listen-datagram", nil, "Datagram address to listen on for socket activation (may be specified multiple times)")
+	flags.BoolVar(&systemdOptions.ResourceLimits, "resource-limits", false, "Translate the container's resource limits into the unit's [Service] section")
+	flags.BoolVar(&systemdOptions.Hardening, "hardening", false, "Add systemd sandboxing directives to the unit's [Service] section")
+	flags.StringVar(&systemdFormat, "format", "", `Print the units as a JSON object of {filename: content} instead of raw unit file text ("json")`)
+}
+
+func systemd(cmd *cobra.Command, args []string) error {
+	report, err := registry.ContainerEngine().GenerateSystemd(context.Background(), args[0], systemdOptions)
+	if err != nil {
+		return err
+	}
+
+	if systemdFormat == "json" {
+		marshalled, err := json.MarshalIndent(report.Units, "", "  ")
+		if err != nil {
+			return err
+		}
+		cmd.Println(string(marshalled))
+		return nil
+	}
+
+	for _, content := range report.Units {
+		fmt.Fprintln(cmd.OutOrStdout(), content)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package play
+
+import (
+	"context"
+
+	"github.com/containers/podman/v3/cmd/podman/registry"
+	"github.com/containers/podman/v3/pkg/domain/entities"
+	"github.com/spf13/cobra"
+)
+
+var (
+	kubeOptions     entities.PlayKubeOptions
+	kubeDescription = `Create and run pods and containers based on a Kubernetes YAML manifest.
+
+Supported manifest kinds: Pod, Deployment, DaemonSet, Job, CronJob,
+ConfigMap, Secret, and PersistentVolumeClaim. Service documents are
+accepted and skipped. Documents are applied in the order they appear in the
+(optionally multi-document) file.`
+	kubeCmd = &cobra.Command{
+		Use:   "kube [options] KUBEFILE",
+		Short: "Play a pod or containers based on a structured input file",
+		Long:  kubeDescription,
+		RunE:  kube,
+		Args:  cobra.ExactArgs(1),
+	}
+)
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: kubeCmd,
+		Parent:  PlayCmd,
+	})
+
+	flags := kubeCmd.Flags()
+	flags.StringVar(&kubeOptions.Network, "network", "", "Connect the pod to this network")
+	flags.BoolVar(&kubeOptions.TLSVerify, "tls-verify", true, "Require HTTPS and verify signatures when contacting registries")
+	flags.StringVar(&kubeOptions.LogDriver, "log-driver", "", "Logging driver for the containers in the pod")
+	flags.BoolVar(&kubeOptions.Start, "start", true, "Start the pod after creating it")
+	flags.StringArrayVar(&kubeOptions.ConfigMaps, "configmap", nil, "Path to a YAML file containing ConfigMaps referenced by the manifest (may be specified multiple times)")
+	flags.StringArrayVar(&kubeOptions.Secrets, "secret", nil, "Path to a YAML file containing Secrets referenced by the manifest (may be specified multiple times)")
+	flags.BoolVar(&kubeOptions.DryRun, "dry-run", false, "Validate and resolve the manifest and report the planned create specs without creating anything")
+	flags.BoolVar(&kubeOptions.Diff, "diff", false, "Report a structured diff against any already-running pod with the same name instead of applying the manifest")
+}
+
+func kube(cmd *cobra.Command, args []string) error {
+	report, err := registry.ContainerEngine().PlayKube(context.Background(), args[0], kubeOptions)
+	if err != nil {
+		return err
+	}
+
+	if kubeOptions.DryRun {
+		for _, plan := range report.Plans {
+			cmd.Println(plan)
+		}
+		return nil
+	}
+	if kubeOptions.Diff {
+		for _, diff := range report.Diffs {
+			cmd.Println(diff)
+		}
+		return nil
+	}
+
+	for _, pod := range report.Pods {
+		cmd.Println("Pod:")
+		cmd.Println(pod.ID)
+		for _, ctr := range pod.Containers {
+			cmd.Println("Container:")
+			cmd.Println(ctr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+// Package play implements the `podman play` family of commands, which
+// apply structured (currently: Kubernetes YAML) manifests.
+package play
+
+import (
+	"github.com/containers/podman/v3/cmd/podman/registry"
+	"github.com/spf13/cobra"
+)
+
+var PlayCmd = &cobra.Command{
+	Use:   "play",
+	Short: "Play a pod or container based on a structured input file",
+}
+
+func init() {
+	registry.Commands = append(registry.Commands, registry.CliCommand{
+		Command: PlayCmd,
+	})
+}
@@ -0,0 +1,188 @@
+package libpod
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeploymentNameLabel and DeploymentReplicasLabel are set by play kube on
+// every pod created from a Deployment manifest, so that `generate kube` can
+// reconstitute the same Deployment (name and replica count) from any one of
+// its pods rather than only ever being able to emit a bare Pod. They are
+// exported so that pkg/specgen/generate/kube (which sets them when creating
+// those pods) and this package (which reads them back) share one
+// definition instead of keeping their own copies in sync by hand.
+const (
+	DeploymentNameLabel     = "io.podman.annotations.deployment.name"
+	DeploymentReplicasLabel = "io.podman.annotations.deployment.replicas"
+)
+
+// GenerateForDeployment reports whether pod was created from a Deployment
+// manifest and, if so, wraps its generated PodTemplateSpec in a Deployment
+// with the original name and replica count. It returns ok == false for pods
+// that were not created from a Deployment, in which case the caller should
+// fall back to emitting a bare Pod as before.
+func GenerateForDeployment(pod *Pod, podTemplate *v1.PodTemplateSpec) (deployment *appsv1.Deployment, ok bool, err error) {
+	name, hasName := pod.Labels()[DeploymentNameLabel]
+	if !hasName {
+		return nil, false, nil
+	}
+
+	replicas := int32(1)
+	if raw, hasReplicas := pod.Labels()[DeploymentReplicasLabel]; hasReplicas {
+		if _, err := fmt.Sscanf(raw, "%d", &replicas); err != nil {
+			return nil, false, errors.Wrapf(err, "pod %s has invalid %s label %q", pod.Name(), DeploymentReplicasLabel, raw)
+		}
+	}
+
+	selector := map[string]string{"app": name}
+	podTemplate.ObjectMeta.Labels = selector
+
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: selector,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: selector},
+			Template: *podTemplate,
+		},
+	}, true, nil
+}
+
+// GenerateForKube builds the v1.Pod manifest (and, as a PodTemplateSpec,
+// the same containers ready to be wrapped by GenerateForDeployment) that
+// running pod's containers would be recreated from by `podman play kube`.
+// Only the fields play kube itself consumes (image, command/args, and env)
+// round-trip; anything podman tracks that Kubernetes has no equivalent for
+// (pod IDs, cgroup state, ...) is intentionally dropped.
+func GenerateForKube(pod *Pod) (*v1.Pod, *v1.PodTemplateSpec, error) {
+	containers, err := pod.AllContainers()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "listing containers in pod %q", pod.Name())
+	}
+
+	var kubeContainers []v1.Container
+	for _, ctr := range containers {
+		kubeContainers = append(kubeContainers, generateKubeContainer(ctr))
+	}
+
+	// Podman tracks restart policy per container, not per pod, so there is
+	// no single value to read back here; "Always" is the value Kubernetes
+	// itself uses when a manifest doesn't set RestartPolicy.
+	podSpec := v1.PodSpec{
+		Containers:    kubeContainers,
+		RestartPolicy: v1.RestartPolicyAlways,
+	}
+
+	kubePod := &v1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   pod.Name(),
+			Labels: pod.Labels(),
+		},
+		Spec: podSpec,
+	}
+
+	podTemplate := &v1.PodTemplateSpec{
+		ObjectMeta: kubePod.ObjectMeta,
+		Spec:       podSpec,
+	}
+
+	return kubePod, podTemplate, nil
+}
+
+// generateKubeContainer translates a single running container back into
+// the v1.Container play kube would have created it from.
+func generateKubeContainer(ctr *Container) v1.Container {
+	_, imageName := ctr.Image()
+	kubeCtr := v1.Container{
+		Name:    ctr.Name(),
+		Image:   imageName,
+		Command: ctr.Spec().Process.Args,
+	}
+
+	for _, kv := range ctr.Spec().Process.Env {
+		idx := strings.Index(kv, "=")
+		if idx < 0 {
+			continue
+		}
+		kubeCtr.Env = append(kubeCtr.Env, v1.EnvVar{Name: kv[:idx], Value: kv[idx+1:]})
+	}
+
+	return kubeCtr
+}
+
+// kubeDoc pairs a manifest object with the order key it should be emitted
+// under in a multi-document YAML stream.
+type kubeDoc struct {
+	order  int
+	object interface{}
+}
+
+// Dependency order for a multi-document manifest: objects a workload
+// references (ConfigMaps, then PVCs) are emitted before the workloads that
+// mount them, so `play kube` can resolve references in a single top-to-bottom
+// pass over the combined file.
+const (
+	orderConfigMap = iota
+	orderPVC
+	orderDeployment
+	orderPod
+)
+
+// GenerateKubeMultiDoc combines ConfigMaps, PersistentVolumeClaims, and
+// Deployments (or bare Pods, for objects GenerateForDeployment didn't
+// convert) into a single "---"-separated YAML stream, in the dependency
+// order play kube needs to resolve them in one pass.
+func GenerateKubeMultiDoc(configMaps []*v1.ConfigMap, pvcs []*v1.PersistentVolumeClaim, workloads []interface{}) (*bytes.Buffer, error) {
+	docs := make([]kubeDoc, 0, len(configMaps)+len(pvcs)+len(workloads))
+	for _, cm := range configMaps {
+		docs = append(docs, kubeDoc{orderConfigMap, cm})
+	}
+	for _, pvc := range pvcs {
+		docs = append(docs, kubeDoc{orderPVC, pvc})
+	}
+	for _, w := range workloads {
+		order := orderPod
+		if _, isDeployment := w.(*appsv1.Deployment); isDeployment {
+			order = orderDeployment
+		}
+		docs = append(docs, kubeDoc{order, w})
+	}
+
+	// stable sort by order, preserving each category's original ordering
+	for i := 1; i < len(docs); i++ {
+		for j := i; j > 0 && docs[j].order < docs[j-1].order; j-- {
+			docs[j], docs[j-1] = docs[j-1], docs[j]
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	for i, doc := range docs {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		marshalled, err := yaml.Marshal(doc.object)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling generated kube manifest")
+		}
+		buf.Write(marshalled)
+	}
+	return buf, nil
+}
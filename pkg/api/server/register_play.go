@@ -34,6 +34,16 @@ func (s *APIServer) registerPlayHandlers(r *mux.Router) error {
 	//    type: boolean
 	//    default: true
 	//    description: Start the pod after creating it.
+	//  - in: query
+	//    name: dryRun
+	//    type: boolean
+	//    default: false
+	//    description: Validate and resolve the manifest and report the resulting create specs without creating anything.
+	//  - in: query
+	//    name: diff
+	//    type: boolean
+	//    default: false
+	//    description: If a pod from this manifest already exists, report a structured diff of image, env, mounts, capabilities, restart policy, and hostAliases instead of creating or updating anything.
 	//  - in: body
 	//    name: request
 	//    description: Kubernetes YAML file.
@@ -0,0 +1,48 @@
+package entities
+
+import "io"
+
+// GenerateSystemdOptions controls the unit(s) GenerateSystemd produces.
+type GenerateSystemdOptions struct {
+	Name             bool
+	New              bool
+	NoHeader         bool
+	RestartPolicy    string
+	StopTimeout      *uint
+	ContainerPrefix  string
+	PodPrefix        string
+	Separator        string
+	Requires         []string
+	Wants            []string
+	After            []string
+	Before           []string
+	Conflicts        []string
+	SdNotifyMode     string
+	SocketActivation bool
+	ListenStreams    []string
+	ListenDatagrams  []string
+	ResourceLimits   bool
+	Hardening        bool
+}
+
+// GenerateKubeOptions controls how GenerateKube renders a pod back into a
+// Kubernetes manifest.
+type GenerateKubeOptions struct {
+	// Service is set by `generate kube --service`. GenerateKube does not
+	// read it: no Service manifest is emitted, only the Pod (or Deployment).
+	Service bool
+}
+
+// GenerateKubeReport is the rendered, multi-document YAML manifest
+// GenerateKube produced from a pod (and, if it was created from a
+// Deployment, the Deployment wrapping it).
+type GenerateKubeReport struct {
+	Reader io.Reader
+}
+
+// GenerateSystemdReport is the unit(s) GenerateSystemd produced, keyed by
+// file name (e.g. "container-name.service", and "container-name.socket"
+// when --socket-activated was requested).
+type GenerateSystemdReport struct {
+	Units map[string]string
+}
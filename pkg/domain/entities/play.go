@@ -0,0 +1,44 @@
+package entities
+
+// PlayKubeOptions controls how a Kubernetes manifest is applied by
+// `podman play kube`.
+type PlayKubeOptions struct {
+	// Network to join the pod's containers to, in addition to the default.
+	Network string
+	// TLSVerify requires HTTPS and verified signatures when pulling images.
+	TLSVerify bool
+	// LogDriver sets the logging driver used by the pod's containers.
+	LogDriver string
+	// Start is whether to start the pod after creating it.
+	Start bool
+	// ConfigMaps are paths to YAML files containing ConfigMaps referenced
+	// by the manifest's envFrom/valueFrom/projected-volume sources.
+	ConfigMaps []string
+	// Secrets are paths to YAML files containing Secrets referenced the
+	// same way as ConfigMaps.
+	Secrets []string
+	// DryRun validates and resolves the manifest and reports the planned
+	// create specs without creating anything.
+	DryRun bool
+	// Diff reports a structured diff against any already-running pod with
+	// the same name instead of creating or updating anything.
+	Diff bool
+}
+
+// PlayKubePod is the per-pod report entry in a PlayKubeReport.
+type PlayKubePod struct {
+	ID         string
+	Containers []string
+}
+
+// PlayKubeReport reports what `podman play kube` did (or, for --dry-run/
+// --diff, would do).
+type PlayKubeReport struct {
+	Pods []PlayKubePod
+	// Plans holds the --dry-run planned-pod JSON, one entry per Pod
+	// document in the manifest, when options.DryRun is set.
+	Plans []string
+	// Diffs holds the --diff structured-diff JSON, one entry per Pod
+	// document that already exists, when options.Diff is set.
+	Diffs []string
+}
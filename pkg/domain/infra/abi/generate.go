@@ -0,0 +1,66 @@
+package abi
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/containers/podman/v3/libpod"
+	"github.com/containers/podman/v3/pkg/domain/entities"
+	systemdgenerate "github.com/containers/podman/v3/pkg/systemd/generate"
+	"github.com/pkg/errors"
+)
+
+// GenerateKube renders nameOrID's pod back into a Kubernetes YAML manifest.
+// A pod play kube created from a Deployment is wrapped back into a
+// Deployment manifest; any other pod is emitted as a bare Pod.
+func (ic *ContainerEngine) GenerateKube(ctx context.Context, nameOrID string, options entities.GenerateKubeOptions) (*entities.GenerateKubeReport, error) {
+	pod, err := ic.Libpod.LookupPod(nameOrID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "looking up pod %q", nameOrID)
+	}
+
+	kubePod, podTemplate, err := libpod.GenerateForKube(pod)
+	if err != nil {
+		return nil, errors.Wrapf(err, "generating kube manifest for pod %q", nameOrID)
+	}
+
+	var workload interface{} = kubePod
+	if deployment, ok, err := libpod.GenerateForDeployment(pod, podTemplate); err != nil {
+		return nil, err
+	} else if ok {
+		workload = deployment
+	}
+
+	buf, err := libpod.GenerateKubeMultiDoc(nil, nil, []interface{}{workload})
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.GenerateKubeReport{Reader: bytes.NewReader(buf.Bytes())}, nil
+}
+
+// GenerateSystemd renders nameOrID's container into one or more systemd
+// unit files: a .service unit always, plus a .socket unit when
+// options.SocketActivation is set.
+func (ic *ContainerEngine) GenerateSystemd(ctx context.Context, nameOrID string, options entities.GenerateSystemdOptions) (*entities.GenerateSystemdReport, error) {
+	ctr, err := ic.Libpod.LookupContainer(nameOrID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "looking up container %q", nameOrID)
+	}
+
+	name, content, err := systemdgenerate.ContainerUnit(ctr, options)
+	if err != nil {
+		return nil, err
+	}
+	units := map[string]string{name + ".service": content}
+
+	if options.SocketActivation {
+		socketName, socketContent, err := systemdgenerate.SocketUnit(ctr, options)
+		if err != nil {
+			return nil, err
+		}
+		units[socketName] = socketContent
+	}
+
+	return &entities.GenerateSystemdReport{Units: units}, nil
+}
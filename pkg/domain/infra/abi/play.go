@@ -0,0 +1,346 @@
+package abi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/containers/podman/v3/libpod"
+	"github.com/containers/podman/v3/pkg/domain/entities"
+	"github.com/containers/podman/v3/pkg/specgen"
+	"github.com/containers/podman/v3/pkg/specgen/generate"
+	"github.com/containers/podman/v3/pkg/specgen/generate/kube"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// typeMeta is used to sniff a manifest document's kind before deciding which
+// concrete type to unmarshal it into.
+type typeMeta struct {
+	Kind string `json:"kind"`
+}
+
+// PlayKube reads a (possibly multi-document) Kubernetes YAML manifest and
+// creates whatever Pod, Deployment, DaemonSet, Job, CronJob, ConfigMap,
+// Secret, and PersistentVolumeClaim documents it contains. Service documents
+// are accepted and skipped. With --dry-run or --diff, only Pod documents are
+// reported on and nothing is created.
+func (ic *ContainerEngine) PlayKube(ctx context.Context, path string, options entities.PlayKubeOptions) (*entities.PlayKubeReport, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %q", path)
+	}
+
+	configMaps, err := readConfigMapFiles(options.ConfigMaps)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := readSecretFiles(options.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &entities.PlayKubeReport{}
+	for _, doc := range splitYAMLDocuments(content) {
+		var meta typeMeta
+		if err := yaml.Unmarshal(doc, &meta); err != nil {
+			return report, errors.Wrap(err, "reading manifest kind")
+		}
+
+		switch meta.Kind {
+		case "ConfigMap":
+			var cm v1.ConfigMap
+			if err := yaml.Unmarshal(doc, &cm); err != nil {
+				return report, err
+			}
+			configMaps[cm.Name] = &cm
+
+		case "Secret":
+			var secret v1.Secret
+			if err := yaml.Unmarshal(doc, &secret); err != nil {
+				return report, err
+			}
+			secrets[secret.Name] = &secret
+
+		case "PersistentVolumeClaim":
+			var pvc v1.PersistentVolumeClaim
+			if err := yaml.Unmarshal(doc, &pvc); err != nil {
+				return report, err
+			}
+			if options.DryRun || options.Diff {
+				continue
+			}
+			if _, err := ic.Libpod.NewVolume(ctx, libpod.WithVolumeName(pvc.Name)); err != nil {
+				return report, errors.Wrapf(err, "creating volume for PersistentVolumeClaim %q", pvc.Name)
+			}
+
+		case "Pod":
+			var pod v1.Pod
+			if err := yaml.Unmarshal(doc, &pod); err != nil {
+				return report, err
+			}
+			if err := ic.playKubePod(ctx, &pod, options, configMaps, secrets, report); err != nil {
+				return report, err
+			}
+
+		case "Deployment":
+			var deployment appsv1.Deployment
+			if err := yaml.Unmarshal(doc, &deployment); err != nil {
+				return report, err
+			}
+			if options.DryRun || options.Diff {
+				continue
+			}
+			pods, err := kube.PlayKubeDeployment(ctx, ic.Libpod, &deployment)
+			if err != nil {
+				return report, err
+			}
+			for _, pod := range pods {
+				report.Pods = append(report.Pods, entities.PlayKubePod{ID: pod.ID()})
+			}
+
+		case "DaemonSet":
+			var daemonSet appsv1.DaemonSet
+			if err := yaml.Unmarshal(doc, &daemonSet); err != nil {
+				return report, err
+			}
+			if options.DryRun || options.Diff {
+				continue
+			}
+			pod, err := kube.PlayKubeDaemonSet(ctx, ic.Libpod, &daemonSet)
+			if err != nil {
+				return report, err
+			}
+			report.Pods = append(report.Pods, entities.PlayKubePod{ID: pod.ID()})
+
+		case "Job":
+			var job batchv1.Job
+			if err := yaml.Unmarshal(doc, &job); err != nil {
+				return report, err
+			}
+			if options.DryRun || options.Diff {
+				continue
+			}
+			podSpec := specgen.NewPodSpecGenerator()
+			pod, err := kube.PlayKubeJob(ctx, ic.Libpod, &job, podSpec)
+			if err != nil {
+				return report, err
+			}
+			report.Pods = append(report.Pods, entities.PlayKubePod{ID: pod.ID()})
+
+		case "CronJob":
+			var cronJob batchv1.CronJob
+			if err := yaml.Unmarshal(doc, &cronJob); err != nil {
+				return report, err
+			}
+			if options.DryRun || options.Diff {
+				continue
+			}
+			pod, err := kube.PlayKubeCronJob(ctx, ic.Libpod, &cronJob)
+			if err != nil {
+				return report, err
+			}
+			report.Pods = append(report.Pods, entities.PlayKubePod{ID: pod.ID()})
+
+		case "Service":
+			// Service documents are skipped; no podman object is created
+			// for them.
+
+		default:
+			return report, errors.Errorf("unsupported kube kind %q", meta.Kind)
+		}
+	}
+
+	return report, nil
+}
+
+// playKubePod handles the Pod kind itself: --dry-run/--diff preview modes,
+// or the real pod-and-containers creation path with probes, resources, and
+// volumes applied.
+func (ic *ContainerEngine) playKubePod(ctx context.Context, pod *v1.Pod, options entities.PlayKubeOptions, configMaps map[string]*v1.ConfigMap, secrets map[string]*v1.Secret, report *entities.PlayKubeReport) error {
+	if options.DryRun {
+		planned, err := kube.PlanPod(pod, configMaps, secrets)
+		if err != nil {
+			return err
+		}
+		planJSON, err := json.Marshal(planned)
+		if err != nil {
+			return err
+		}
+		report.Plans = append(report.Plans, string(planJSON))
+		return nil
+	}
+
+	if options.Diff {
+		existing, err := ic.Libpod.LookupPod(pod.Name)
+		if err == nil {
+			planned, err := kube.PlanPod(pod, configMaps, secrets)
+			if err != nil {
+				return err
+			}
+			diff, err := kube.DiffPod(existing, planned)
+			if err != nil {
+				return err
+			}
+			diffJSON, err := json.Marshal(diff)
+			if err != nil {
+				return err
+			}
+			report.Diffs = append(report.Diffs, string(diffJSON))
+		}
+		return nil
+	}
+
+	return ic.createKubePod(ctx, pod, options, configMaps, secrets, report)
+}
+
+// splitYAMLDocuments splits a "---"-separated multi-document YAML stream
+// into its individual documents.
+func splitYAMLDocuments(content []byte) [][]byte {
+	var docs [][]byte
+	for _, doc := range bytes.Split(content, []byte("\n---")) {
+		trimmed := bytes.TrimSpace(doc)
+		if len(trimmed) == 0 {
+			continue
+		}
+		docs = append(docs, trimmed)
+	}
+	return docs
+}
+
+// readConfigMapFiles decodes the --configmap manifests play kube was
+// handed, keyed by ConfigMap name so later documents can resolve
+// envFrom/valueFrom/projected-volume references against them.
+func readConfigMapFiles(paths []string) (map[string]*v1.ConfigMap, error) {
+	configMaps := make(map[string]*v1.ConfigMap)
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading configmap %q", path)
+		}
+		var cm v1.ConfigMap
+		if err := yaml.Unmarshal(content, &cm); err != nil {
+			return nil, errors.Wrapf(err, "parsing configmap %q", path)
+		}
+		configMaps[cm.Name] = &cm
+	}
+	return configMaps, nil
+}
+
+// readSecretFiles is readConfigMapFiles' Secret counterpart.
+func readSecretFiles(paths []string) (map[string]*v1.Secret, error) {
+	secrets := make(map[string]*v1.Secret)
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading secret %q", path)
+		}
+		var secret v1.Secret
+		if err := yaml.Unmarshal(content, &secret); err != nil {
+			return nil, errors.Wrapf(err, "parsing secret %q", path)
+		}
+		secrets[secret.Name] = &secret
+	}
+	return secrets, nil
+}
+
+// createKubePod builds the pod and its containers from a Pod manifest,
+// naming each container "<pod>-<container>" and applying its env, probes,
+// and resource limits (via the pkg/specgen/generate/kube helpers) before
+// starting it. The manifest's volumes and volumeMounts are not mounted. Any
+// initContainers run to completion, in order, before the first regular
+// container is even created, the same ordering kubelet guarantees.
+func (ic *ContainerEngine) createKubePod(ctx context.Context, pod *v1.Pod, options entities.PlayKubeOptions, configMaps map[string]*v1.ConfigMap, secrets map[string]*v1.Secret, report *entities.PlayKubeReport) error {
+	podSpec := specgen.NewPodSpecGenerator()
+	podSpec.PodBasicConfig.Name = pod.Name
+
+	libpodPod, err := generate.MakePod(podSpec, ic.Libpod)
+	if err != nil {
+		return errors.Wrapf(err, "creating pod %q", pod.Name)
+	}
+
+	entry := entities.PlayKubePod{ID: libpodPod.ID()}
+
+	for _, c := range pod.Spec.InitContainers {
+		if err := ic.runInitContainer(ctx, libpodPod, pod.Name, c); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range pod.Spec.Containers {
+		s := specgen.NewSpecGenerator(c.Image, false)
+		s.Name = fmt.Sprintf("%s-%s", pod.Name, c.Name)
+		s.Pod = libpodPod.ID()
+		s.Command = append(append([]string{}, c.Command...), c.Args...)
+
+		env, err := kube.ResolveEnv(c, configMaps, secrets)
+		if err != nil {
+			return errors.Wrapf(err, "container %q env", c.Name)
+		}
+		s.Env = env
+
+		if c.LivenessProbe != nil {
+			if err := kube.SetLivenessProbe(s, c.LivenessProbe, string(pod.Spec.RestartPolicy)); err != nil {
+				return errors.Wrapf(err, "container %q liveness probe", c.Name)
+			}
+		}
+		if c.ReadinessProbe != nil {
+			if err := kube.SetReadinessProbe(s, c.ReadinessProbe); err != nil {
+				return errors.Wrapf(err, "container %q readiness probe", c.Name)
+			}
+		}
+		if c.StartupProbe != nil {
+			kube.SetStartupProbe(s, c.StartupProbe)
+		}
+		if err := kube.SetResources(s, c.Resources); err != nil {
+			return errors.Wrapf(err, "container %q resources", c.Name)
+		}
+
+		ctr, err := generate.MakeContainer(ctx, ic.Libpod, s)
+		if err != nil {
+			return errors.Wrapf(err, "creating container %q", c.Name)
+		}
+		if options.Start {
+			if err := ctr.Start(ctx, false); err != nil {
+				return errors.Wrapf(err, "starting container %q", c.Name)
+			}
+		}
+		entry.Containers = append(entry.Containers, ctr.ID())
+	}
+
+	report.Pods = append(report.Pods, entry)
+	return nil
+}
+
+// runInitContainer creates, starts, and waits out a single initContainer,
+// naming it "<pod>-<container>-init" so it's distinguishable from the
+// regular container of the same name play kube may also create. It returns
+// an error — stopping the rest of the pod from ever being created — unless
+// the init container exits 0, matching kubelet's initContainer semantics.
+func (ic *ContainerEngine) runInitContainer(ctx context.Context, pod *libpod.Pod, podName string, c v1.Container) error {
+	s := specgen.NewSpecGenerator(c.Image, false)
+	s.Name = fmt.Sprintf("%s-%s-init", podName, c.Name)
+	s.Pod = pod.ID()
+	s.Command = append(append([]string{}, c.Command...), c.Args...)
+
+	ctr, err := generate.MakeContainer(ctx, ic.Libpod, s)
+	if err != nil {
+		return errors.Wrapf(err, "creating init container %q", c.Name)
+	}
+	if err := ctr.Start(ctx, false); err != nil {
+		return errors.Wrapf(err, "starting init container %q", c.Name)
+	}
+	exitCode, err := ctr.Wait(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "waiting for init container %q", c.Name)
+	}
+	if exitCode != 0 {
+		return errors.Errorf("init container %q exited with code %d", c.Name, exitCode)
+	}
+	return nil
+}
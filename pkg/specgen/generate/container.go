@@ -0,0 +1,31 @@
+package generate
+
+import (
+	"context"
+
+	"github.com/containers/podman/v3/libpod"
+	"github.com/containers/podman/v3/pkg/specgen"
+	"github.com/pkg/errors"
+)
+
+// MakeContainer validates a SpecGenerator and creates the libpod Container
+// it describes, without starting it.
+func MakeContainer(ctx context.Context, rt *libpod.Runtime, s *specgen.SpecGenerator) (*libpod.Container, error) {
+	if s == nil {
+		return nil, errors.New("no container spec provided")
+	}
+	if err := s.Validate(); err != nil {
+		return nil, errors.Wrap(err, "validating container spec")
+	}
+
+	spec, options, err := s.ToOCISpec(ctx, rt)
+	if err != nil {
+		return nil, err
+	}
+
+	ctr, err := rt.NewContainer(ctx, spec, options...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating container %q", s.Name)
+	}
+	return ctr, nil
+}
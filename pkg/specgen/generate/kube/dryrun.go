@@ -0,0 +1,327 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/containers/podman/v3/libpod"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// PlannedContainer is the --dry-run/--diff preview of the container play
+// kube would create for one container in a pod's manifest: every field a
+// user would otherwise only discover by creating the pod and inspecting it.
+type PlannedContainer struct {
+	Name          string            `json:"name"`
+	Image         string            `json:"image"`
+	Env           map[string]string `json:"env,omitempty"`
+	Mounts        []string          `json:"mounts,omitempty"`
+	Capabilities  []string          `json:"capabilities,omitempty"`
+	RestartPolicy string            `json:"restartPolicy"`
+	HostAliases   []string          `json:"hostAliases,omitempty"`
+}
+
+// PlannedPod is the full --dry-run preview of a manifest: the pod name and
+// the planned container specs, without anything having actually been
+// created.
+type PlannedPod struct {
+	Name       string             `json:"name"`
+	Containers []PlannedContainer `json:"containers"`
+}
+
+// PlanPod resolves a Pod manifest's env/envFrom references against the
+// already-resolved configmaps/secrets play kube's --configmap/--secret
+// handling produces, and reports the container specs that would result,
+// without creating the pod or any of its containers.
+func PlanPod(pod *v1.Pod, configMaps map[string]*v1.ConfigMap, secrets map[string]*v1.Secret) (*PlannedPod, error) {
+	planned := &PlannedPod{Name: pod.Name}
+
+	for _, c := range pod.Spec.Containers {
+		env, err := ResolveEnv(c, configMaps, secrets)
+		if err != nil {
+			return nil, errors.Wrapf(err, "container %q", c.Name)
+		}
+
+		var mounts []string
+		for _, vm := range c.VolumeMounts {
+			mounts = append(mounts, fmt.Sprintf("%s:%s", vm.Name, vm.MountPath))
+		}
+
+		var hostAliases []string
+		for _, ha := range pod.Spec.HostAliases {
+			for _, hostname := range ha.Hostnames {
+				hostAliases = append(hostAliases, fmt.Sprintf("%s:%s", hostname, ha.IP))
+			}
+		}
+
+		planned.Containers = append(planned.Containers, PlannedContainer{
+			Name:          c.Name,
+			Image:         c.Image,
+			Env:           env,
+			Mounts:        mounts,
+			Capabilities:  containerCapabilities(c),
+			RestartPolicy: string(pod.Spec.RestartPolicy),
+			HostAliases:   hostAliases,
+		})
+	}
+
+	return planned, nil
+}
+
+// ResolveEnv merges a container's Env entries (literal values and
+// configMapKeyRef/secretKeyRef valueFrom references) with whatever its
+// EnvFrom configmap/secret references resolve to. play kube's real
+// container-creation path uses it too, so --dry-run's preview matches what
+// play kube would actually set.
+func ResolveEnv(c v1.Container, configMaps map[string]*v1.ConfigMap, secrets map[string]*v1.Secret) (map[string]string, error) {
+	env := make(map[string]string)
+
+	for _, from := range c.EnvFrom {
+		switch {
+		case from.ConfigMapRef != nil:
+			cm, ok := configMaps[from.ConfigMapRef.Name]
+			if !ok {
+				return nil, errors.Errorf("configmap %q not found", from.ConfigMapRef.Name)
+			}
+			for k, v := range cm.Data {
+				env[from.Prefix+k] = v
+			}
+		case from.SecretRef != nil:
+			secret, ok := secrets[from.SecretRef.Name]
+			if !ok {
+				return nil, errors.Errorf("secret %q not found", from.SecretRef.Name)
+			}
+			for k, v := range secret.Data {
+				env[from.Prefix+k] = string(v)
+			}
+		}
+	}
+
+	for _, e := range c.Env {
+		value := e.Value
+		if from := e.ValueFrom; from != nil && from.ConfigMapKeyRef != nil {
+			cm, ok := configMaps[from.ConfigMapKeyRef.Name]
+			if !ok {
+				return nil, errors.Errorf("configmap %q not found", from.ConfigMapKeyRef.Name)
+			}
+			value = cm.Data[from.ConfigMapKeyRef.Key]
+		} else if from != nil && from.SecretKeyRef != nil {
+			secret, ok := secrets[from.SecretKeyRef.Name]
+			if !ok {
+				return nil, errors.Errorf("secret %q not found", from.SecretKeyRef.Name)
+			}
+			value = string(secret.Data[from.SecretKeyRef.Key])
+		}
+		env[e.Name] = value
+	}
+
+	return env, nil
+}
+
+// containerCapabilities flattens a container's SecurityContext capability
+// add/drop lists into the "+CAP"/"-CAP" notation podman's own
+// --cap-add/--cap-drop preview output already uses.
+func containerCapabilities(c v1.Container) []string {
+	if c.SecurityContext == nil || c.SecurityContext.Capabilities == nil {
+		return nil
+	}
+	var caps []string
+	for _, add := range c.SecurityContext.Capabilities.Add {
+		caps = append(caps, "+"+string(add))
+	}
+	for _, drop := range c.SecurityContext.Capabilities.Drop {
+		caps = append(caps, "-"+string(drop))
+	}
+	return caps
+}
+
+// PodDiff is the structured --diff report between a running pod and what a
+// manifest would produce if applied.
+type PodDiff struct {
+	PodName       string     `json:"podName"`
+	ImageChanges  []string   `json:"imageChanges,omitempty"`
+	EnvChanges    []string   `json:"envChanges,omitempty"`
+	MountChanges  []string   `json:"mountChanges,omitempty"`
+	CapChanges    []string   `json:"capChanges,omitempty"`
+	RestartPolicy *[2]string `json:"restartPolicy,omitempty"`
+	HostAliases   []string   `json:"hostAliasChanges,omitempty"`
+}
+
+// DiffPod compares a running pod's containers against a freshly planned pod
+// for the same manifest, reporting every field --diff promises to cover:
+// image, env, mounts, capabilities, restart policy, and hostAliases.
+func DiffPod(existing *libpod.Pod, planned *PlannedPod) (*PodDiff, error) {
+	diff := &PodDiff{PodName: existing.Name()}
+
+	containers, err := existing.AllContainers()
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing containers in pod %q", existing.Name())
+	}
+	existingByName := make(map[string]*libpod.Container, len(containers))
+	for _, ctr := range containers {
+		existingByName[containerKubeName(ctr)] = ctr
+	}
+
+	for _, c := range planned.Containers {
+		old, ok := existingByName[c.Name]
+		if !ok {
+			diff.ImageChanges = append(diff.ImageChanges, fmt.Sprintf("%s: container added (image %s)", c.Name, c.Image))
+			continue
+		}
+		if _, oldImage := old.Image(); oldImage != "" && oldImage != c.Image {
+			diff.ImageChanges = append(diff.ImageChanges, fmt.Sprintf("%s: %s -> %s", c.Name, oldImage, c.Image))
+		}
+		diff.EnvChanges = append(diff.EnvChanges, diffEnv(c.Name, old, c)...)
+		diff.MountChanges = append(diff.MountChanges, diffMounts(c.Name, old, c)...)
+		diff.CapChanges = append(diff.CapChanges, diffCaps(c.Name, old, c)...)
+	}
+
+	if len(planned.Containers) > 0 {
+		first := planned.Containers[0]
+		if old, ok := existingByName[first.Name]; ok {
+			if existingPolicy := old.Config().RestartPolicy; existingPolicy != first.RestartPolicy {
+				diff.RestartPolicy = &[2]string{existingPolicy, first.RestartPolicy}
+			}
+			diff.HostAliases = diffHostAliases(old, first.HostAliases)
+		}
+	}
+
+	sort.Strings(diff.ImageChanges)
+	sort.Strings(diff.EnvChanges)
+	sort.Strings(diff.MountChanges)
+	sort.Strings(diff.CapChanges)
+	sort.Strings(diff.HostAliases)
+	return diff, nil
+}
+
+// diffEnv reports every environment variable that was added, removed, or
+// changed between the running container's OCI spec and the planned one.
+func diffEnv(name string, old *libpod.Container, planned PlannedContainer) []string {
+	existing := make(map[string]string)
+	for _, kv := range old.Spec().Process.Env {
+		if k, v, ok := splitEnv(kv); ok {
+			existing[k] = v
+		}
+	}
+
+	var changes []string
+	for k, v := range planned.Env {
+		if ov, ok := existing[k]; !ok {
+			changes = append(changes, fmt.Sprintf("%s: %s added (%s)", name, k, v))
+		} else if ov != v {
+			changes = append(changes, fmt.Sprintf("%s: %s changed (%s -> %s)", name, k, ov, v))
+		}
+	}
+	for k, ov := range existing {
+		if _, ok := planned.Env[k]; !ok {
+			changes = append(changes, fmt.Sprintf("%s: %s removed (%s)", name, k, ov))
+		}
+	}
+	return changes
+}
+
+func splitEnv(kv string) (string, string, bool) {
+	idx := strings.Index(kv, "=")
+	if idx < 0 {
+		return "", "", false
+	}
+	return kv[:idx], kv[idx+1:], true
+}
+
+// diffMounts reports every mount path that is only present in the running
+// container or only in the plan. It compares by mount destination, since
+// that's the only part a kube VolumeMount ("name:path") and an OCI spec
+// mount have in common.
+func diffMounts(name string, old *libpod.Container, planned PlannedContainer) []string {
+	existing := make(map[string]bool)
+	for _, m := range old.Spec().Mounts {
+		existing[m.Destination] = true
+	}
+
+	plannedDest := make(map[string]bool, len(planned.Mounts))
+	for _, m := range planned.Mounts {
+		dest := m
+		if idx := strings.Index(m, ":"); idx >= 0 {
+			dest = m[idx+1:]
+		}
+		plannedDest[dest] = true
+	}
+
+	var changes []string
+	for dest := range plannedDest {
+		if !existing[dest] {
+			changes = append(changes, fmt.Sprintf("%s: %s added", name, dest))
+		}
+	}
+	for dest := range existing {
+		if !plannedDest[dest] {
+			changes = append(changes, fmt.Sprintf("%s: %s removed", name, dest))
+		}
+	}
+	return changes
+}
+
+// diffCaps reports any +CAP/-CAP the plan asks for that the running
+// container's bounding set disagrees with.
+func diffCaps(name string, old *libpod.Container, planned PlannedContainer) []string {
+	bounding := make(map[string]bool)
+	if proc := old.Spec().Process; proc != nil && proc.Capabilities != nil {
+		for _, cap := range proc.Capabilities.Bounding {
+			bounding[cap] = true
+		}
+	}
+
+	var changes []string
+	for _, c := range planned.Capabilities {
+		switch {
+		case strings.HasPrefix(c, "+") && !bounding[strings.TrimPrefix(c, "+")]:
+			changes = append(changes, fmt.Sprintf("%s: %s not granted", name, strings.TrimPrefix(c, "+")))
+		case strings.HasPrefix(c, "-") && bounding[strings.TrimPrefix(c, "-")]:
+			changes = append(changes, fmt.Sprintf("%s: %s not dropped", name, strings.TrimPrefix(c, "-")))
+		}
+	}
+	return changes
+}
+
+// diffHostAliases reports any --add-host ("host:ip") entry the plan would set
+// that the given running container doesn't already have, or vice versa.
+func diffHostAliases(old *libpod.Container, planned []string) []string {
+	existing := make(map[string]bool)
+	for _, ha := range old.Config().HostAdd {
+		existing[ha] = true
+	}
+	plannedSet := make(map[string]bool, len(planned))
+	for _, ha := range planned {
+		plannedSet[ha] = true
+	}
+
+	var changes []string
+	for ha := range plannedSet {
+		if !existing[ha] {
+			changes = append(changes, fmt.Sprintf("%s added", ha))
+		}
+	}
+	for ha := range existing {
+		if !plannedSet[ha] {
+			changes = append(changes, fmt.Sprintf("%s removed", ha))
+		}
+	}
+	return changes
+}
+
+// containerKubeName strips the "<pod>-" prefix play kube puts in front of a
+// container's name so a running container can be matched back up to the
+// manifest container name it was created from. It keeps only the text after
+// the last '-', so a manifest container name that itself contains '-' is not
+// recovered whole.
+func containerKubeName(ctr *libpod.Container) string {
+	name := ctr.Name()
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '-' {
+			return name[i+1:]
+		}
+	}
+	return name
+}
@@ -0,0 +1,195 @@
+// Package kube translates Kubernetes workload manifests into the
+// specgen.PodSpecGenerator/SpecGenerator structures used to create the
+// underlying pods and containers, and back. It is invoked from the
+// `podman play kube` and `podman generate kube` command paths.
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/containers/podman/v3/libpod"
+	"github.com/containers/podman/v3/pkg/specgen"
+	"github.com/containers/podman/v3/pkg/specgen/generate"
+	systemdgenerate "github.com/containers/podman/v3/pkg/systemd/generate"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+// cronJobScheduleLabel is set on the pod backing a CronJob's current Job so
+// that the schedule that created it is discoverable via `podman pod inspect`
+// without having to keep the CronJob manifest around.
+const cronJobScheduleLabel = "io.podman.annotations.cronjob.schedule"
+
+// JobPodName returns the name of the pod a Job's containers run in.
+func JobPodName(job *batchv1.Job) string {
+	return job.Name + "-job"
+}
+
+// PlayKubeJob creates the pod backing a Job manifest and runs its template
+// container to completion, honoring completions, parallelism and
+// backoffLimit.  The resulting pod has the infra container podman creates
+// for every pod, plus one container per completion for each container in the
+// Job's pod template (completions+1 in all for a single-container template).
+func PlayKubeJob(ctx context.Context, rt *libpod.Runtime, job *batchv1.Job, podSpec *specgen.PodSpecGenerator) (*libpod.Pod, error) {
+	if len(job.Spec.Template.Spec.Containers) == 0 {
+		return nil, errors.Errorf("job %q has no containers in its pod template", job.Name)
+	}
+
+	completions := int32(1)
+	if job.Spec.Completions != nil {
+		completions = *job.Spec.Completions
+	}
+	parallelism := int32(1)
+	if job.Spec.Parallelism != nil && *job.Spec.Parallelism > 0 {
+		parallelism = *job.Spec.Parallelism
+	}
+	backoffLimit := int32(6)
+	if job.Spec.BackoffLimit != nil {
+		backoffLimit = *job.Spec.BackoffLimit
+	}
+
+	podSpec.PodBasicConfig.Name = JobPodName(job)
+	pod, err := generate.MakePod(podSpec, rt)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating pod for job %q", job.Name)
+	}
+
+	specs, err := generate.SpecGensFromPodTemplate(&job.Spec.Template, pod, completions)
+	if err != nil {
+		return nil, err
+	}
+
+	return pod, runCompletions(ctx, rt, pod, specs, int(parallelism), int(backoffLimit))
+}
+
+// runCompletions drives the completions through to a terminal state, running
+// at most parallelism containers concurrently and retrying a failing
+// completion up to backoffLimit times before giving up on it.
+func runCompletions(ctx context.Context, rt *libpod.Runtime, pod *libpod.Pod, specs []*specgen.SpecGenerator, parallelism, backoffLimit int) error {
+	tokens := make(chan struct{}, parallelism)
+	errCh := make(chan error, len(specs))
+
+	for i, spec := range specs {
+		tokens <- struct{}{}
+		go func(i int, spec *specgen.SpecGenerator) {
+			defer func() { <-tokens }()
+			errCh <- runToCompletion(ctx, rt, spec, backoffLimit)
+		}(i, spec)
+	}
+
+	var firstErr error
+	for range specs {
+		if err := <-errCh; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// runToCompletion creates and starts a single completion's container,
+// retrying on failure until it succeeds or backoffLimit retries have been
+// exhausted. A failed container is removed before its retry, which reuses
+// its name; the container of the last failed attempt is kept.
+func runToCompletion(ctx context.Context, rt *libpod.Runtime, spec *specgen.SpecGenerator, backoffLimit int) error {
+	var lastErr error
+	for attempt := 0; attempt <= backoffLimit; attempt++ {
+		ctr, err := generate.MakeContainer(ctx, rt, spec)
+		if err != nil {
+			return err
+		}
+		if err := ctr.Start(ctx, false); err != nil {
+			return err
+		}
+		exitCode, err := ctr.Wait(ctx)
+		if err == nil && exitCode == 0 {
+			return nil
+		}
+		lastErr = errors.Errorf("completion %q exited %d: %v", ctr.Name(), exitCode, err)
+		if attempt < backoffLimit {
+			if err := rt.RemoveContainer(ctx, ctr, true, false); err != nil {
+				return errors.Wrapf(err, "removing failed completion %q before retrying", ctr.Name())
+			}
+		}
+	}
+	return errors.Wrapf(lastErr, "exceeded backoffLimit of %d", backoffLimit)
+}
+
+// PlayKubeCronJob runs the CronJob's Job template once, labels the Job's pod
+// with the CronJob's schedule, and registers a systemd timer for that
+// schedule (see registerCronTimer for what the timer does and does not do).
+func PlayKubeCronJob(ctx context.Context, rt *libpod.Runtime, cronJob *batchv1.CronJob) (*libpod.Pod, error) {
+	// The Job template keeps its own name (rather than being renamed to
+	// the CronJob's) so that `podman pod inspect <jobTemplate.Name>-job`
+	// finds the pod this run created; re-running on schedule reuses the
+	// same name rather than minting a new one per run, unlike a real
+	// Kubernetes CronJob controller.
+	jobTemplate := cronJob.Spec.JobTemplate.DeepCopy()
+
+	podSpec := specgen.NewPodSpecGenerator()
+	if podSpec.Labels == nil {
+		podSpec.Labels = make(map[string]string)
+	}
+	podSpec.Labels[cronJobScheduleLabel] = cronJob.Spec.Schedule
+
+	pod, err := PlayKubeJob(ctx, rt, &batchv1.Job{
+		ObjectMeta: jobTemplate.ObjectMeta,
+		Spec:       jobTemplate.Spec,
+	}, podSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := registerCronTimer(cronJob.Name, cronJob.Spec.Schedule); err != nil {
+		return pod, errors.Wrapf(err, "pod %s created but failed to register its schedule", pod.Name())
+	}
+	return pod, nil
+}
+
+// registerCronTimer translates the CronJob's schedule into an
+// OnCalendar= expression and hands it to systemdgenerate.WriteTimerUnit to
+// write and start a `podman-cronjob-<name>.timer` unit. Only the timer unit
+// is written: no `podman-cronjob-<name>.service` is generated, so the Job
+// template is not re-run on schedule unless such a service is installed by
+// other means.
+func registerCronTimer(name, schedule string) error {
+	calendar, err := cronToOnCalendar(schedule)
+	if err != nil {
+		return err
+	}
+	return systemdgenerate.WriteTimerUnit(fmt.Sprintf("podman-cronjob-%s", name), calendar)
+}
+
+// cronToOnCalendar converts a minute-granularity cron expression into the
+// equivalent systemd OnCalendar= expression. Only the two forms a CronJob's
+// schedule actually needs are supported: every minute ("* * * * *") and
+// every N minutes with every other field wildcarded ("*/N * * * *");
+// anything with an hour, day-of-month, month, or day-of-week constraint is
+// rejected rather than silently mistranslated.
+func cronToOnCalendar(schedule string) (string, error) {
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return "", errors.Errorf("cron schedule %q must have five fields", schedule)
+	}
+	minute, rest := fields[0], fields[1:]
+	for _, f := range rest {
+		if f != "*" {
+			return "", errors.Errorf("cron schedule %q: only minute-granularity schedules (hour/day/month/weekday all \"*\") are supported", schedule)
+		}
+	}
+
+	if minute == "*" {
+		return "*-*-* *:*:00", nil
+	}
+
+	if !strings.HasPrefix(minute, "*/") {
+		return "", errors.Errorf("cron schedule %q: unsupported minute field %q", schedule, minute)
+	}
+	step := strings.TrimPrefix(minute, "*/")
+	if n, err := strconv.Atoi(step); err != nil || n <= 0 || n > 59 {
+		return "", errors.Errorf("cron schedule %q: invalid step %q", schedule, step)
+	}
+	return fmt.Sprintf("*-*-* *:0/%s:00", step), nil
+}
@@ -0,0 +1,116 @@
+package kube
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/podman/v3/pkg/specgen"
+	v1 "k8s.io/api/core/v1"
+)
+
+// SetLivenessProbe translates a Kubernetes livenessProbe into the
+// container's healthcheck.
+//
+// podman v3's ContainerHealthCheckConfig has no --health-on-failure action
+// (that's a podman v4 addition), so a failing healthcheck here only
+// surfaces as Config.Healthcheck/State.Health.Status on inspect — it does
+// not restart the container on its own. Kubernetes semantics would also
+// only restart the Pod when restartPolicy isn't Never, so restartPolicy is
+// accepted here for callers that drive their own restart loop off of it,
+// even though this function doesn't act on it directly.
+func SetLivenessProbe(s *specgen.SpecGenerator, probe *v1.Probe, restartPolicy string) error {
+	if probe == nil {
+		return nil
+	}
+	return setHealthCheck(s, probe)
+}
+
+// SetReadinessProbe translates a Kubernetes readinessProbe into the
+// container's healthcheck. A failing readiness probe only has to surface
+// as State.Health.Status == "unhealthy", which is exactly what a plain
+// healthcheck already gives us.
+func SetReadinessProbe(s *specgen.SpecGenerator, probe *v1.Probe) error {
+	if probe == nil {
+		return nil
+	}
+	return setHealthCheck(s, probe)
+}
+
+// setHealthCheck fills in the HealthConfig shared by liveness and readiness
+// probes: the probe's action becomes --health-cmd, and its timing fields
+// become --health-interval/--health-timeout/--health-retries/
+// --health-start-period.
+func setHealthCheck(s *specgen.SpecGenerator, probe *v1.Probe) error {
+	cmd, err := probeCommand(probe)
+	if err != nil {
+		return err
+	}
+
+	interval := time.Duration(probe.PeriodSeconds) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	timeout := time.Duration(probe.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	retries := probe.FailureThreshold
+	if retries <= 0 {
+		retries = 3
+	}
+	startPeriod := time.Duration(probe.InitialDelaySeconds) * time.Second
+
+	s.ContainerHealthCheckConfig.HealthConfig = &manifest.Schema2HealthConfig{
+		Test:        cmd,
+		Interval:    interval,
+		Timeout:     timeout,
+		Retries:     int(retries),
+		StartPeriod: startPeriod,
+	}
+	return nil
+}
+
+// probeCommand derives the podman HEALTHCHECK CMD for a probe's action.
+// exec is passed through as a CMD argv (not CMD-SHELL, which takes a single
+// shell string and would mangle a multi-token command like
+// ["test","-f","/tmp/ready"]); httpGet and tcpSocket have no native podman
+// healthcheck equivalent, so they're translated into a shell command run
+// inside the container's own namespace via CMD-SHELL.
+func probeCommand(probe *v1.Probe) ([]string, error) {
+	switch {
+	case probe.Exec != nil && len(probe.Exec.Command) > 0:
+		return append([]string{"CMD"}, probe.Exec.Command...), nil
+	case probe.HTTPGet != nil:
+		return httpGetProbeCommand(probe.HTTPGet), nil
+	case probe.TCPSocket != nil:
+		return tcpSocketProbeCommand(probe.TCPSocket), nil
+	default:
+		return nil, fmt.Errorf("probe has no supported action")
+	}
+}
+
+// SetStartupProbe folds a startupProbe into the container's liveness
+// healthcheck: it widens the healthcheck's StartPeriod to cover the
+// startup probe's own allowed startup time (initialDelaySeconds plus
+// periodSeconds*failureThreshold), so that liveness failures during that
+// window don't count against the liveness failure threshold. This must be
+// called after SetLivenessProbe.
+func SetStartupProbe(s *specgen.SpecGenerator, startupProbe *v1.Probe) {
+	if startupProbe == nil || s.ContainerHealthCheckConfig.HealthConfig == nil {
+		return
+	}
+	period := startupProbe.PeriodSeconds
+	if period <= 0 {
+		period = 10
+	}
+	threshold := startupProbe.FailureThreshold
+	if threshold <= 0 {
+		threshold = 3
+	}
+	allowance := time.Duration(startupProbe.InitialDelaySeconds)*time.Second + time.Duration(period*threshold)*time.Second
+
+	if allowance > s.ContainerHealthCheckConfig.HealthConfig.StartPeriod {
+		s.ContainerHealthCheckConfig.HealthConfig.StartPeriod = allowance
+	}
+}
@@ -0,0 +1,41 @@
+package kube
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// httpGetProbeCommand builds a CMD-SHELL healthcheck that fetches the
+// probe's path and port inside the container, failing the healthcheck on
+// any non-2xx/3xx response the same way kubelet's httpGet probe would.
+// wget (busybox's own applet) is used rather than curl, which busybox does
+// not ship.
+func httpGetProbeCommand(action *v1.HTTPGetAction) []string {
+	scheme := "http"
+	if action.Scheme == v1.URISchemeHTTPS {
+		scheme = "https"
+	}
+	host := action.Host
+	if host == "" {
+		host = "localhost"
+	}
+	path := action.Path
+	if path == "" {
+		path = "/"
+	}
+	url := fmt.Sprintf("%s://%s:%s%s", scheme, host, action.Port.String(), path)
+	return []string{"CMD-SHELL", fmt.Sprintf("wget -S -q -O /dev/null %q", url)}
+}
+
+// tcpSocketProbeCommand builds a CMD-SHELL healthcheck that opens a TCP
+// connection to the probe's port inside the container, the same check
+// kubelet's tcpSocket probe performs. This shells out to /bin/sh's own
+// /dev/tcp redirection rather than nc, which busybox does not ship.
+func tcpSocketProbeCommand(action *v1.TCPSocketAction) []string {
+	host := action.Host
+	if host == "" {
+		host = "localhost"
+	}
+	return []string{"CMD-SHELL", fmt.Sprintf("(echo -n > /dev/tcp/%s/%s) 2>/dev/null", host, action.Port.String())}
+}
@@ -0,0 +1,89 @@
+package kube
+
+import (
+	"github.com/containers/podman/v3/pkg/specgen"
+	units "github.com/docker/go-units"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// cpuPeriod is the cgroup CPU period podman's own --cpus flag uses; a CPU
+// limit is expressed against it as a quota, the same way --cpus=N is
+// implemented as --cpu-quota=N*period.
+const cpuPeriod = 100000
+
+// SetResources translates a container's resources.limits and
+// resources.requests into the cgroup constraints podman's --cpus,
+// --cpu-shares, --memory, --memory-reservation, and --storage-opt size
+// flags would produce, and applies them to the container's spec.
+func SetResources(s *specgen.SpecGenerator, resources v1.ResourceRequirements) error {
+	cpu, memory, err := toLinuxResources(resources)
+	if err != nil {
+		return err
+	}
+	if cpu != nil || memory != nil {
+		if s.ResourceLimits == nil {
+			s.ResourceLimits = &specs.LinuxResources{}
+		}
+		s.ResourceLimits.CPU = cpu
+		s.ResourceLimits.Memory = memory
+	}
+
+	if storageLimit, ok := resources.Limits[v1.ResourceEphemeralStorage]; ok {
+		if s.StorageOpts == nil {
+			s.StorageOpts = make(map[string]string)
+		}
+		s.StorageOpts["size"] = units.BytesSize(float64(storageLimit.Value()))
+	}
+
+	return nil
+}
+
+// toLinuxResources builds the LinuxCPU and LinuxMemory fragments of an OCI
+// spec's resource limits, covering whichever of cpu/memory limits/requests
+// are actually set.
+func toLinuxResources(resources v1.ResourceRequirements) (*specs.LinuxCPU, *specs.LinuxMemory, error) {
+	var cpu *specs.LinuxCPU
+	var memory *specs.LinuxMemory
+
+	if cpuLimit, ok := resources.Limits[v1.ResourceCPU]; ok {
+		milli := cpuLimit.MilliValue()
+		if milli <= 0 {
+			return nil, nil, errors.Errorf("cpu limit %q must be positive", cpuLimit.String())
+		}
+		quota := int64(milli) * cpuPeriod / 1000
+		period := uint64(cpuPeriod)
+		cpu = &specs.LinuxCPU{Quota: &quota, Period: &period}
+	}
+
+	if cpuRequest, ok := resources.Requests[v1.ResourceCPU]; ok {
+		milli := cpuRequest.MilliValue()
+		if milli <= 0 {
+			return nil, nil, errors.Errorf("cpu request %q must be positive", cpuRequest.String())
+		}
+		shares := uint64(milli) * 1024 / 1000
+		if shares < 2 {
+			shares = 2
+		}
+		if cpu == nil {
+			cpu = &specs.LinuxCPU{}
+		}
+		cpu.Shares = &shares
+	}
+
+	if memLimit, ok := resources.Limits[v1.ResourceMemory]; ok {
+		bytes := memLimit.Value()
+		memory = &specs.LinuxMemory{Limit: &bytes}
+	}
+
+	if memRequest, ok := resources.Requests[v1.ResourceMemory]; ok {
+		bytes := memRequest.Value()
+		if memory == nil {
+			memory = &specs.LinuxMemory{}
+		}
+		memory.Reservation = &bytes
+	}
+
+	return cpu, memory, nil
+}
@@ -0,0 +1,200 @@
+package kube
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// downwardAPIPodInfo carries the subset of a pod's own metadata that
+// downwardAPI volume items (and the downwardAPI source of a projected
+// volume) are allowed to reference.
+type downwardAPIPodInfo struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// downwardAPIField resolves a fieldRef path (e.g. "metadata.name",
+// "metadata.labels") against the pod's own metadata. It only supports the
+// handful of fields play kube can actually populate without a full
+// Kubernetes API server behind it.
+func downwardAPIField(info downwardAPIPodInfo, fieldPath string) (string, error) {
+	switch fieldPath {
+	case "metadata.name":
+		return info.Name, nil
+	case "metadata.namespace":
+		return info.Namespace, nil
+	case "metadata.labels":
+		return formatKeyValueLines(info.Labels), nil
+	case "metadata.annotations":
+		return formatKeyValueLines(info.Annotations), nil
+	default:
+		return "", errors.Errorf("downwardAPI field %q is not supported", fieldPath)
+	}
+}
+
+// formatKeyValueLines renders a map the same way Kubernetes' kubelet does
+// for a labels/annotations downwardAPI file: one `key="value"` pair per
+// line, sorted for a stable, diffable file.
+func formatKeyValueLines(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	for i := 1; i < len(keys); i++ {
+		for j := i; j > 0 && keys[j] < keys[j-1]; j-- {
+			keys[j], keys[j-1] = keys[j-1], keys[j]
+		}
+	}
+	out := ""
+	for _, k := range keys {
+		out += fmt.Sprintf("%s=%q\n", k, m[k])
+	}
+	return out
+}
+
+// MaterializeDownwardAPIVolume writes a standalone downwardAPI volume's
+// items to a fresh directory under base, returning the directory so the
+// caller can bind-mount it read-only into the container at pod start.
+func MaterializeDownwardAPIVolume(base, volumeName string, source *v1.DownwardAPIVolumeSource, info downwardAPIPodInfo) (string, error) {
+	dir, err := ioutil.TempDir(base, "downwardapi-"+volumeName+"-")
+	if err != nil {
+		return "", errors.Wrap(err, "creating downwardAPI volume directory")
+	}
+	for _, item := range source.Items {
+		if item.FieldRef == nil {
+			continue
+		}
+		value, err := downwardAPIField(info, item.FieldRef.FieldPath)
+		if err != nil {
+			return "", err
+		}
+		if err := writeProjectedFile(dir, item.Path, value); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// MaterializeProjectedVolume writes every source in a projected volume's
+// Sources list into a single directory, merging configMap, secret,
+// serviceAccountToken, and downwardAPI entries so the container sees one
+// unified mount. Any other source kind is rejected with an error.
+//
+// resolveConfigMap is handed the same configmap lookup play kube already
+// uses for --configmap and envFrom: it returns the decoded ConfigMap or an
+// error if the name isn't known. resolveSecret is its secret counterpart.
+// issueServiceAccountToken mints the token a serviceAccountToken source
+// projects; podman has no service-account API of its own, so it's left to
+// the caller to supply (e.g. a fixed per-pod token, matching how podman
+// already treats the rest of the Kubernetes RBAC surface as out of scope).
+func MaterializeProjectedVolume(
+	base, volumeName string,
+	source *v1.ProjectedVolumeSource,
+	info downwardAPIPodInfo,
+	resolveConfigMap func(name string) (*v1.ConfigMap, error),
+	resolveSecret func(name string) (*v1.Secret, error),
+	issueServiceAccountToken func() (string, error),
+) (string, error) {
+	dir, err := ioutil.TempDir(base, "projected-"+volumeName+"-")
+	if err != nil {
+		return "", errors.Wrap(err, "creating projected volume directory")
+	}
+
+	for _, proj := range source.Sources {
+		switch {
+		case proj.ConfigMap != nil:
+			cm, err := resolveConfigMap(proj.ConfigMap.Name)
+			if err != nil {
+				return "", err
+			}
+			if err := projectConfigMapKeys(dir, cm.Data, proj.ConfigMap.Items); err != nil {
+				return "", err
+			}
+		case proj.Secret != nil:
+			secret, err := resolveSecret(proj.Secret.Name)
+			if err != nil {
+				return "", err
+			}
+			data := make(map[string]string, len(secret.Data))
+			for key, value := range secret.Data {
+				data[key] = string(value)
+			}
+			if err := projectConfigMapKeys(dir, data, proj.Secret.Items); err != nil {
+				return "", err
+			}
+		case proj.ServiceAccountToken != nil:
+			if issueServiceAccountToken == nil {
+				return "", errors.Errorf("projected volume %q: serviceAccountToken source requires a token issuer", volumeName)
+			}
+			token, err := issueServiceAccountToken()
+			if err != nil {
+				return "", errors.Wrapf(err, "issuing serviceAccountToken for projected volume %q", volumeName)
+			}
+			if err := writeProjectedFile(dir, proj.ServiceAccountToken.Path, token); err != nil {
+				return "", err
+			}
+		case proj.DownwardAPI != nil:
+			for _, item := range proj.DownwardAPI.Items {
+				if item.FieldRef == nil {
+					continue
+				}
+				value, err := downwardAPIField(info, item.FieldRef.FieldPath)
+				if err != nil {
+					return "", err
+				}
+				if err := writeProjectedFile(dir, item.Path, value); err != nil {
+					return "", err
+				}
+			}
+		default:
+			return "", errors.Errorf("projected volume %q: unsupported source", volumeName)
+		}
+	}
+
+	return dir, nil
+}
+
+// projectConfigMapKeys writes the selected keys of a ConfigMap's Data into
+// dir, honoring each item's path remapping the same way kubelet does; if
+// items is empty every key is projected under its own name.
+func projectConfigMapKeys(dir string, data map[string]string, items []v1.KeyToPath) error {
+	if len(items) == 0 {
+		for key, value := range data {
+			if err := writeProjectedFile(dir, key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, item := range items {
+		value, ok := data[item.Key]
+		if !ok {
+			return errors.Errorf("configmap key %q referenced by projected volume not found", item.Key)
+		}
+		if err := writeProjectedFile(dir, item.Path, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeProjectedFile writes value to path under dir, creating any
+// intermediate directories the item's path requests (Kubernetes allows
+// path to contain slashes).
+func writeProjectedFile(dir, path, value string) error {
+	full := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return errors.Wrapf(err, "creating directory for projected file %q", path)
+	}
+	if err := ioutil.WriteFile(full, []byte(value), 0644); err != nil {
+		return errors.Wrapf(err, "writing projected file %q", path)
+	}
+	return nil
+}
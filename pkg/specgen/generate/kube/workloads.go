@@ -0,0 +1,96 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/containers/podman/v3/libpod"
+	"github.com/containers/podman/v3/pkg/specgen"
+	"github.com/containers/podman/v3/pkg/specgen/generate"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// PlayKubeDeployment creates one pod per replica from a Deployment's pod
+// template, naming them "<deployment>-pod-<n>" to avoid collisions, and
+// starts their containers.
+func PlayKubeDeployment(ctx context.Context, rt *libpod.Runtime, deployment *appsv1.Deployment) ([]*libpod.Pod, error) {
+	if len(deployment.Spec.Template.Spec.Containers) == 0 {
+		return nil, errors.Errorf("deployment %q has no containers in its pod template", deployment.Name)
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	pods := make([]*libpod.Pod, 0, replicas)
+	for i := int32(0); i < replicas; i++ {
+		podName := fmt.Sprintf("%s-pod-%d", deployment.Name, i)
+		pod, err := createReplicaPod(ctx, rt, podName, &deployment.Spec.Template, map[string]string{
+			libpod.DeploymentNameLabel:     deployment.Name,
+			libpod.DeploymentReplicasLabel: strconv.Itoa(int(replicas)),
+		})
+		if err != nil {
+			return pods, errors.Wrapf(err, "creating replica %d of deployment %q", i, deployment.Name)
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// PlayKubeDaemonSet creates the single pod a DaemonSet runs on a podman
+// host: podman has exactly one node, so "one pod per node" collapses to one
+// pod named "<daemonset>-pod".
+func PlayKubeDaemonSet(ctx context.Context, rt *libpod.Runtime, daemonSet *appsv1.DaemonSet) (*libpod.Pod, error) {
+	if len(daemonSet.Spec.Template.Spec.Containers) == 0 {
+		return nil, errors.Errorf("daemonset %q has no containers in its pod template", daemonSet.Name)
+	}
+
+	podName := fmt.Sprintf("%s-pod", daemonSet.Name)
+	return createReplicaPod(ctx, rt, podName, &daemonSet.Spec.Template, nil)
+}
+
+// createReplicaPod extracts a pod spec from a PodTemplateSpec (shared by
+// Deployment and DaemonSet, which differ only in how many pods they create
+// and what names they give them), applies any extra labels the caller wants
+// recorded on the pod, and creates and starts the resulting pod and its
+// containers, with each container's resource requests/limits applied.
+func createReplicaPod(ctx context.Context, rt *libpod.Runtime, podName string, template *v1.PodTemplateSpec, extraLabels map[string]string) (*libpod.Pod, error) {
+	podSpec := specgen.NewPodSpecGenerator()
+	podSpec.PodBasicConfig.Name = podName
+	if len(extraLabels) > 0 {
+		if podSpec.Labels == nil {
+			podSpec.Labels = make(map[string]string)
+		}
+		for k, v := range extraLabels {
+			podSpec.Labels[k] = v
+		}
+	}
+
+	pod, err := generate.MakePod(podSpec, rt)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating pod %q", podName)
+	}
+
+	specs, err := generate.SpecGensFromPodTemplate(template, pod, 1)
+	if err != nil {
+		return pod, err
+	}
+
+	for i, spec := range specs {
+		if err := SetResources(spec, template.Spec.Containers[i].Resources); err != nil {
+			return pod, err
+		}
+		ctr, err := generate.MakeContainer(ctx, rt, spec)
+		if err != nil {
+			return pod, err
+		}
+		if err := ctr.Start(ctx, false); err != nil {
+			return pod, err
+		}
+	}
+	return pod, nil
+}
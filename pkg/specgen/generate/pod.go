@@ -0,0 +1,34 @@
+// Package generate builds libpod pods and containers from specgen
+// SpecGenerators/PodSpecGenerators. It sits between the specgen kube
+// translation package (pkg/specgen/generate/kube) and libpod itself: kube
+// code turns a manifest into spec generators, and the functions here turn
+// those generators into the running pods and containers libpod manages.
+package generate
+
+import (
+	"github.com/containers/podman/v3/libpod"
+	"github.com/containers/podman/v3/pkg/specgen"
+	"github.com/pkg/errors"
+)
+
+// MakePod validates a PodSpecGenerator and creates the libpod Pod it
+// describes, without starting any containers in it yet.
+func MakePod(podSpec *specgen.PodSpecGenerator, rt *libpod.Runtime) (*libpod.Pod, error) {
+	if podSpec == nil {
+		return nil, errors.New("no pod spec provided")
+	}
+	if err := podSpec.Validate(); err != nil {
+		return nil, errors.Wrap(err, "validating pod spec")
+	}
+
+	options, err := podSpec.PodCreationOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	pod, err := rt.NewPod(options...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating pod %q", podSpec.Name)
+	}
+	return pod, nil
+}
@@ -0,0 +1,57 @@
+package generate
+
+import (
+	"fmt"
+
+	"github.com/containers/podman/v3/libpod"
+	"github.com/containers/podman/v3/pkg/specgen"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// SpecGensFromPodTemplate builds a base SpecGenerator (name, image, command,
+// args) for every container in a PodTemplateSpec, repeated count times for
+// callers like Job completions that need several independent copies of the
+// same container set. It does not apply probes, resource limits, or volumes
+// — callers that need those (see pkg/specgen/generate/kube) apply them to
+// the returned specs themselves, since doing it here would require this
+// package to import kube, which already imports this package.
+func SpecGensFromPodTemplate(template *v1.PodTemplateSpec, pod *libpod.Pod, count int) ([]*specgen.SpecGenerator, error) {
+	if len(template.Spec.Containers) == 0 {
+		return nil, errors.New("pod template has no containers")
+	}
+	if count <= 0 {
+		count = 1
+	}
+
+	var specs []*specgen.SpecGenerator
+	for i := 0; i < count; i++ {
+		for _, c := range template.Spec.Containers {
+			specs = append(specs, specGenFromContainer(c, pod, i, count))
+		}
+	}
+	return specs, nil
+}
+
+// specGenFromContainer builds a SpecGenerator's basic identity and process
+// fields from a single Kubernetes container, named "<pod>-<container>" like
+// play kube's other containers. index/count add a "-<index>" suffix to keep
+// the name unique when it's one of several repeated copies (count > 1).
+func specGenFromContainer(c v1.Container, pod *libpod.Pod, index, count int) *specgen.SpecGenerator {
+	s := specgen.NewSpecGenerator(c.Image, false)
+	s.Name = fmt.Sprintf("%s-%s", pod.Name(), c.Name)
+	if count > 1 {
+		s.Name = fmt.Sprintf("%s-%s-%d", pod.Name(), c.Name, index)
+	}
+	s.Pod = pod.ID()
+	s.Command = append(append([]string{}, c.Command...), c.Args...)
+
+	for _, env := range c.Env {
+		if s.Env == nil {
+			s.Env = make(map[string]string)
+		}
+		s.Env[env.Name] = env.Value
+	}
+
+	return s
+}
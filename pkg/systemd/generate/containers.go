@@ -13,11 +13,20 @@ import (
 	"github.com/containers/podman/v3/pkg/domain/entities"
 	"github.com/containers/podman/v3/pkg/systemd/define"
 	"github.com/containers/podman/v3/version"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 )
 
+// Valid values for entities.GenerateSystemdOptions.SdNotifyMode.
+const (
+	sdNotifyModeConmon    = "conmon"
+	sdNotifyModeContainer = "container"
+	sdNotifyModeHealthy   = "healthy"
+	sdNotifyModeIgnore    = "ignore"
+)
+
 // containerInfo contains data required for generating a container's systemd
 // unit file.
 type containerInfo struct {
@@ -87,6 +96,69 @@ type containerInfo struct {
 	// Location of the RunRoot for the container.  Required for ensuring the tmpfs
 	// or volume exists and is mounted when coming online at boot.
 	RunRoot string
+	// SocketActivated indicates that a paired .socket unit exists for this
+	// service and that the ExecStart command should forward the sockets
+	// systemd hands it into the container via --preserve-fds.
+	SocketActivated bool
+	// ListenStreams are the addresses (host:port, or a path for a Unix
+	// socket) the paired .socket unit should accept TCP/Unix connections on.
+	ListenStreams []string
+	// ListenDatagrams are the addresses the paired .socket unit should
+	// accept UDP datagrams on.
+	ListenDatagrams []string
+	// NumFDs is the number of file descriptors systemd passes to the unit
+	// via the paired .socket; used to size --preserve-fds.
+	NumFDs int
+	// SdNotifyMode is the resolved `--sdnotify` mode applied to the
+	// container: conmon, container, healthy, or ignore.
+	SdNotifyMode string
+	// Requires is a list of services to add as Requires= dependencies
+	// (strong ordering and existence requirement), from --requires.
+	Requires []string
+	// Wants is a list of services to add as Wants= dependencies (weak
+	// ordering, no existence requirement), from --wants.
+	Wants []string
+	// Before is a list of services that must start after this unit, from
+	// --before.
+	Before []string
+	// After is a list of services this unit starts after, from --after.
+	// Independent from the implicit After= generated for BoundToServices.
+	After []string
+	// Conflicts is a list of services that cannot run at the same time as
+	// this unit, from --conflicts.
+	Conflicts []string
+	// CPUQuota mirrors the container's CPU cgroup limit, e.g. "50%", for
+	// CPUQuota= when --resource-limits is set.
+	CPUQuota string
+	// MemoryMax mirrors the container's memory limit in bytes, e.g.
+	// "536870912", for MemoryMax= when --resource-limits is set.
+	MemoryMax string
+	// TasksMax mirrors the container's pids limit for TasksMax= when
+	// --resource-limits is set.
+	TasksMax uint64
+	// IOWeight mirrors the container's blkio weight for IOWeight= when
+	// --resource-limits is set.
+	IOWeight uint64
+	// NoNewPrivileges renders NoNewPrivileges= when --hardening is set.
+	NoNewPrivileges bool
+	// ProtectSystem renders ProtectSystem=, e.g. "strict" or "full", when
+	// --hardening is set.
+	ProtectSystem string
+	// ProtectHome renders ProtectHome= when --hardening is set.
+	ProtectHome bool
+	// PrivateTmp renders PrivateTmp= when --hardening is set.
+	PrivateTmp bool
+	// CapabilityBoundingSet renders CapabilityBoundingSet= from the
+	// container's capability bounding set when --hardening is set.
+	CapabilityBoundingSet string
+	// Image is the name of the image the container was created from.
+	// Only used for translating the unit into a play-kube manifest via
+	// KubeYAMLFromUnit.
+	Image string
+	// PortMappings are the container's published ports.  Only used for
+	// translating the unit into a play-kube manifest via
+	// KubeYAMLFromUnit, where they become a Service kind.
+	PortMappings []containerPortMapping
 }
 
 const containerTemplate = headerTemplate + `
@@ -94,6 +166,21 @@ const containerTemplate = headerTemplate + `
 BindsTo={{{{- range $index, $value := .BoundToServices -}}}}{{{{if $index}}}} {{{{end}}}}{{{{ $value }}}}.service{{{{end}}}}
 After={{{{- range $index, $value := .BoundToServices -}}}}{{{{if $index}}}} {{{{end}}}}{{{{ $value }}}}.service{{{{end}}}}
 {{{{- end}}}}
+{{{{- if .Requires}}}}
+Requires={{{{- range $index, $value := .Requires -}}}}{{{{if $index}}}} {{{{end}}}}{{{{ $value }}}}.service{{{{end}}}}
+{{{{- end}}}}
+{{{{- if .Wants}}}}
+Wants={{{{- range $index, $value := .Wants -}}}}{{{{if $index}}}} {{{{end}}}}{{{{ $value }}}}.service{{{{end}}}}
+{{{{- end}}}}
+{{{{- if .Before}}}}
+Before={{{{- range $index, $value := .Before -}}}}{{{{if $index}}}} {{{{end}}}}{{{{ $value }}}}.service{{{{end}}}}
+{{{{- end}}}}
+{{{{- if .After}}}}
+After={{{{- range $index, $value := .After -}}}}{{{{if $index}}}} {{{{end}}}}{{{{ $value }}}}.service{{{{end}}}}
+{{{{- end}}}}
+{{{{- if .Conflicts}}}}
+Conflicts={{{{- range $index, $value := .Conflicts -}}}}{{{{if $index}}}} {{{{end}}}}{{{{ $value }}}}.service{{{{end}}}}
+{{{{- end}}}}
 
 [Service]
 Environment={{{{.EnvVariable}}}}=%n
@@ -119,24 +206,343 @@ Type={{{{.Type}}}}
 {{{{- if .NotifyAccess}}}}
 NotifyAccess={{{{.NotifyAccess}}}}
 {{{{- end}}}}
+{{{{- if .CPUQuota}}}}
+CPUQuota={{{{.CPUQuota}}}}
+{{{{- end}}}}
+{{{{- if .MemoryMax}}}}
+MemoryMax={{{{.MemoryMax}}}}
+{{{{- end}}}}
+{{{{- if .TasksMax}}}}
+TasksMax={{{{.TasksMax}}}}
+{{{{- end}}}}
+{{{{- if .IOWeight}}}}
+IOWeight={{{{.IOWeight}}}}
+{{{{- end}}}}
+{{{{- if .NoNewPrivileges}}}}
+NoNewPrivileges=yes
+{{{{- end}}}}
+{{{{- if .ProtectSystem}}}}
+ProtectSystem={{{{.ProtectSystem}}}}
+{{{{- end}}}}
+{{{{- if .ProtectHome}}}}
+ProtectHome=yes
+{{{{- end}}}}
+{{{{- if .PrivateTmp}}}}
+PrivateTmp=yes
+{{{{- end}}}}
+{{{{- if .CapabilityBoundingSet}}}}
+CapabilityBoundingSet={{{{.CapabilityBoundingSet}}}}
+{{{{- end}}}}
 
 [Install]
 WantedBy=multi-user.target default.target
 `
 
+const socketTemplate = headerTemplate + `
+[Socket]
+{{{{- range .ListenStreams}}}}
+ListenStream={{{{.}}}}
+{{{{- end}}}}
+{{{{- range .ListenDatagrams}}}}
+ListenDatagram={{{{.}}}}
+{{{{- end}}}}
+
+[Install]
+WantedBy=sockets.target
+`
+
+// SocketUnit generates the paired .socket unit for a container created with
+// --socket-activated.  It must be started once, before the .service unit, so
+// that systemd owns the listening sockets and can start the container on
+// demand.
+func SocketUnit(ctr *libpod.Container, options entities.GenerateSystemdOptions) (string, string, error) {
+	info, err := generateContainerInfo(ctr, options)
+	if err != nil {
+		return "", "", err
+	}
+	if len(options.ListenStreams) == 0 && len(options.ListenDatagrams) == 0 {
+		return "", "", errors.Errorf("socket activation requires at least one --listen address")
+	}
+	info.SocketActivated = true
+	info.ListenStreams = options.ListenStreams
+	info.ListenDatagrams = options.ListenDatagrams
+	info.NumFDs = len(info.ListenStreams) + len(info.ListenDatagrams)
+
+	templ, err := template.New("socket_template").Delims("{{{{", "}}}}").Parse(socketTemplate)
+	if err != nil {
+		return "", "", errors.Wrap(err, "error parsing systemd socket template")
+	}
+
+	var buf bytes.Buffer
+	if err := templ.Execute(&buf, info); err != nil {
+		return "", "", err
+	}
+
+	return info.ServiceName + ".socket", buf.String(), nil
+}
+
+const kubeYamlTemplate = `apiVersion: v1
+kind: Pod
+metadata:
+{{- if .Annotations}}
+  annotations:
+{{- range $key, $value := .Annotations}}
+    {{$key}}: "{{$value}}"
+{{- end}}
+{{- end}}
+  labels:
+    app: {{.Name}}
+  name: {{.Name}}
+spec:
+  restartPolicy: {{.RestartPolicy}}
+  containers:
+{{- range .Containers}}
+  - name: {{.ServiceName}}
+    image: {{.Image}}
+{{- if .Env}}
+    env:
+{{- range .Env}}
+    - name: {{.Name}}
+      value: "{{.Value}}"
+{{- end}}
+{{- end}}
+{{- if .Ports}}
+    ports:
+{{- range .Ports}}
+    - containerPort: {{.ContainerPort}}
+      protocol: {{.Protocol}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .ServicePorts}}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{.Name}}
+spec:
+  selector:
+    app: {{.Name}}
+  ports:
+{{- range .ServicePorts}}
+  - port: {{.HostPort}}
+    targetPort: {{.ContainerPort}}
+    protocol: {{.Protocol}}
+{{- end}}
+{{- end}}
+`
+
+// containerPortMapping is the generate-systemd-local view of a container's
+// published port, used to populate kubeYamlPod.Ports/ServicePorts.
+type containerPortMapping struct {
+	HostPort      uint16
+	ContainerPort uint16
+	Protocol      string
+}
+
+// kubeYamlPod is the data fed to kubeYamlTemplate.  It mirrors the subset of
+// a play-kube Pod (and, if the pod publishes ports, companion Service) that
+// can be derived from the containerInfo structs of the units sharing a pod.
+type kubeYamlPod struct {
+	Name          string
+	RestartPolicy string
+	Annotations   map[string]string
+	Containers    []kubeYamlContainer
+	ServicePorts  []containerPortMapping
+}
+
+type kubeYamlContainer struct {
+	ServiceName string
+	Image       string
+	Env         []kubeYamlEnv
+	Ports       []containerPortMapping
+}
+
+type kubeYamlEnv struct {
+	Name  string
+	Value string
+}
+
+// kubeRestartPolicy translates a systemd Restart= value into the closest
+// matching Kubernetes Pod restartPolicy.  Kubernetes only knows Always,
+// OnFailure and Never, so anything systemd-specific (on-abnormal,
+// on-watchdog, on-abort, ...) is folded into Always, matching systemd's own
+// fallback behavior of restarting on most non-explicit-success exits.
+func kubeRestartPolicy(restart string) string {
+	switch restart {
+	case "no":
+		return "Never"
+	case "on-failure":
+		return "OnFailure"
+	default:
+		return "Always"
+	}
+}
+
+// kubeEnvFromUnit turns a unit's user-set environment into the list form
+// play kube expects.  Only ExtraEnvs is used: containerEnv is the fully
+// resolved process environment (PATH, HOSTNAME, TERM, container=podman,
+// ...) inherited from the image and the runtime, not what the user actually
+// passed via --env, and would otherwise pollute the round-tripped manifest.
+func kubeEnvFromUnit(info *containerInfo) []kubeYamlEnv {
+	envs := make([]kubeYamlEnv, 0, len(info.ExtraEnvs))
+	for _, raw := range info.ExtraEnvs {
+		split := strings.SplitN(raw, "=", 2)
+		if len(split) != 2 {
+			continue
+		}
+		envs = append(envs, kubeYamlEnv{Name: split[0], Value: split[1]})
+	}
+	return envs
+}
+
+// podGroupKey returns the key infos sharing a pod are grouped by: the pod's
+// service name if the unit is part of one, or the unit's own service name if
+// it is standalone.
+func podGroupKey(info *containerInfo) string {
+	if info.Pod != nil {
+		return info.Pod.ServiceName
+	}
+	return info.ServiceName
+}
+
+// KubeYAMLFromUnit translates one or more previously generated containerInfo
+// structs into a multi-document play-kube manifest: one Pod (plus a
+// companion Service, if any container in it publishes ports) per distinct
+// pod among the given units.  Containers sharing the same Pod (i.e.,
+// info.Pod) are grouped into a single Pod manifest; standalone containers
+// each become their own single-container Pod.  This lets the output of
+// `podman generate systemd --new` be replayed with `podman play kube`, or
+// POSTed directly to /libpod/play/kube.
+//
+// BoundToServices has no direct Kubernetes equivalent, so it is preserved as
+// a best-effort annotation rather than dropped silently.
+func KubeYAMLFromUnit(infos ...*containerInfo) (string, error) {
+	if len(infos) == 0 {
+		return "", errors.Errorf("no units given to translate into a Kubernetes YAML file")
+	}
+
+	var order []string
+	pods := make(map[string]*kubeYamlPod)
+
+	for _, info := range infos {
+		key := podGroupKey(info)
+		pod, ok := pods[key]
+		if !ok {
+			name := info.ServiceName
+			restartPolicy := info.RestartPolicy
+			if info.Pod != nil {
+				name = info.Pod.ServiceName
+			}
+			pod = &kubeYamlPod{
+				Name:          name,
+				RestartPolicy: kubeRestartPolicy(restartPolicy),
+			}
+			pods[key] = pod
+			order = append(order, key)
+		}
+
+		if len(info.BoundToServices) > 0 {
+			if pod.Annotations == nil {
+				pod.Annotations = make(map[string]string)
+			}
+			pod.Annotations[info.ServiceName+".bound-to.systemd.io"] = strings.Join(info.BoundToServices, ",")
+		}
+
+		pod.Containers = append(pod.Containers, kubeYamlContainer{
+			ServiceName: info.ServiceName,
+			Image:       info.Image,
+			Env:         kubeEnvFromUnit(info),
+			Ports:       info.PortMappings,
+		})
+		pod.ServicePorts = append(pod.ServicePorts, info.PortMappings...)
+	}
+
+	templ, err := template.New("kube_yaml_template").Parse(kubeYamlTemplate)
+	if err != nil {
+		return "", errors.Wrap(err, "error parsing kube yaml template")
+	}
+
+	var buf bytes.Buffer
+	for i, key := range order {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		if err := templ.Execute(&buf, pods[key]); err != nil {
+			return "", err
+		}
+	}
+
+	return buf.String(), nil
+}
+
 // ContainerUnit generates a systemd unit for the specified container.  Based
 // on the options, the return value might be the entire unit or a file it has
 // been written to.
 func ContainerUnit(ctr *libpod.Container, options entities.GenerateSystemdOptions) (string, string, error) {
-	info, err := generateContainerInfo(ctr, options)
+	unit, err := GenerateContainerUnit(ctr, options)
 	if err != nil {
 		return "", "", err
 	}
+	return unit.Name, unit.Content, nil
+}
+
+// GeneratedUnit is the structured, machine-readable counterpart to the
+// (name, content) pair returned by ContainerUnit.  It carries the resolved
+// containerInfo fields used to build the ini-style unit, so that Go callers
+// of GenerateContainerUnit don't have to re-parse the generated unit file.
+// `podman generate systemd --format=json` does not print these fields: it
+// prints only a map of unit file name to unit file content.
+type GeneratedUnit struct {
+	// Name is the systemd service name, without the .service suffix.
+	Name string
+	// Content is the full, rendered unit file.
+	Content string
+	// ExecStart is the resolved command systemd runs to start the unit.
+	ExecStart string
+	// ExecStop is the resolved command systemd runs to stop the unit.
+	ExecStop string
+	// ExecStopPost is the resolved cleanup command run after stopping.
+	ExecStopPost string
+	// TimeoutStopSec is the resolved stop timeout, in seconds.
+	TimeoutStopSec uint
+	// ExtraEnvs are the environment variables referenced by key only in
+	// the container's create command (only set with --new).
+	ExtraEnvs []string
+}
+
+// GenerateContainerUnit generates a systemd unit for the specified container
+// and returns it along with the resolved fields used to render it.  This is
+// the programmatic counterpart to ContainerUnit, which only returns the
+// rendered unit's name and content.
+func GenerateContainerUnit(ctr *libpod.Container, options entities.GenerateSystemdOptions) (*GeneratedUnit, error) {
+	info, err := generateContainerInfo(ctr, options)
+	if err != nil {
+		return nil, err
+	}
+	if options.SocketActivation {
+		if !options.New {
+			return nil, errors.Errorf("socket activation requires --new")
+		}
+		info.SocketActivated = true
+		info.ListenStreams = options.ListenStreams
+		info.ListenDatagrams = options.ListenDatagrams
+		info.NumFDs = len(info.ListenStreams) + len(info.ListenDatagrams)
+	}
 	content, err := executeContainerTemplate(info, options)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
-	return info.ServiceName, content, nil
+
+	return &GeneratedUnit{
+		Name:           info.ServiceName,
+		Content:        content,
+		ExecStart:      info.ExecStart,
+		ExecStop:       info.ExecStop,
+		ExecStopPost:   info.ExecStopPost,
+		TimeoutStopSec: info.TimeoutStopSec,
+		ExtraEnvs:      info.ExtraEnvs,
+	}, nil
 }
 
 func generateContainerInfo(ctr *libpod.Container, options entities.GenerateSystemdOptions) (*containerInfo, error) {
@@ -158,6 +564,10 @@ func generateContainerInfo(ctr *libpod.Container, options entities.GenerateSyste
 		return nil, errors.Errorf("cannot use --new on container %q: no create command found: only works on containers created directly with podman but not via REST API", ctr.ID())
 	}
 
+	if options.SdNotifyMode == sdNotifyModeHealthy && config.HealthCheckConfig == nil {
+		return nil, errors.Errorf("cannot use --sdnotify=healthy on container %q: no healthcheck configured", ctr.ID())
+	}
+
 	nameOrID, serviceName := containerServiceName(ctr, options)
 
 	var runRoot string
@@ -172,6 +582,22 @@ func generateContainerInfo(ctr *libpod.Container, options entities.GenerateSyste
 
 	envs := config.Spec.Process.Env
 
+	_, imageName := ctr.Image()
+
+	var portMappings []containerPortMapping
+	for _, pm := range config.PortMappings {
+		portMappings = append(portMappings, containerPortMapping{
+			HostPort:      uint16(pm.HostPort),
+			ContainerPort: uint16(pm.ContainerPort),
+			Protocol:      pm.Protocol,
+		})
+	}
+
+	sdNotifyMode := options.SdNotifyMode
+	if sdNotifyMode == "" {
+		sdNotifyMode = sdNotifyModeConmon
+	}
+
 	info := containerInfo{
 		ServiceName:       serviceName,
 		ContainerNameOrID: nameOrID,
@@ -182,11 +608,90 @@ func generateContainerInfo(ctr *libpod.Container, options entities.GenerateSyste
 		CreateCommand:     createCommand,
 		RunRoot:           runRoot,
 		containerEnv:      envs,
+		SdNotifyMode:      sdNotifyMode,
+		Image:             imageName,
+		PortMappings:      portMappings,
+		Requires:          options.Requires,
+		Wants:             options.Wants,
+		Before:            options.Before,
+		After:             options.After,
+		Conflicts:         options.Conflicts,
+	}
+
+	if options.ResourceLimits {
+		setResourceLimits(&info, config.Spec.Linux)
+	}
+	if options.Hardening {
+		setHardening(&info, config.Spec)
 	}
 
 	return &info, nil
 }
 
+// setResourceLimits translates the container's cgroup resource constraints
+// into the systemd unit properties that enforce the same limits, so that a
+// container restarted by systemd (after Podman itself was killed) keeps the
+// same constraints at the unit level.
+func setResourceLimits(info *containerInfo, linux *specs.Linux) {
+	if linux == nil || linux.Resources == nil {
+		return
+	}
+	resources := linux.Resources
+
+	if cpu := resources.CPU; cpu != nil && cpu.Quota != nil && cpu.Period != nil && *cpu.Period > 0 {
+		percent := float64(*cpu.Quota) / float64(*cpu.Period) * 100
+		info.CPUQuota = fmt.Sprintf("%.0f%%", percent)
+	}
+
+	if memory := resources.Memory; memory != nil && memory.Limit != nil {
+		info.MemoryMax = fmt.Sprintf("%d", *memory.Limit)
+	}
+
+	if pids := resources.Pids; pids != nil && pids.Limit > 0 {
+		info.TasksMax = uint64(pids.Limit)
+	}
+
+	if blockIO := resources.BlockIO; blockIO != nil && blockIO.Weight != nil {
+		info.IOWeight = uint64(*blockIO.Weight)
+	}
+}
+
+// setHardening translates the container's security configuration into the
+// equivalent systemd namespace-hardening directives for the unit running
+// podman itself.  Directives that could plausibly break the container are
+// derived from its actual mounts rather than applied unconditionally.
+func setHardening(info *containerInfo, spec *specs.Spec) {
+	if spec == nil || spec.Process == nil {
+		return
+	}
+
+	info.NoNewPrivileges = spec.Process.NoNewPrivileges
+
+	// ProtectSystem=full still leaves /var (where GraphRoot typically
+	// lives) and /run (where RunRoot lives) writable, unlike "strict",
+	// which would prevent the unit from creating its own pid/cid files.
+	info.ProtectSystem = "full"
+
+	var hasHomeMount, hasTmpMount bool
+	for _, m := range spec.Mounts {
+		switch {
+		case strings.HasPrefix(m.Source, "/home"), strings.HasPrefix(m.Source, "/root"):
+			hasHomeMount = true
+		case m.Destination == "/tmp":
+			hasTmpMount = true
+		}
+	}
+
+	// Only hide the host's home directories / private /tmp when the
+	// container isn't legitimately bind-mounting host paths under them.
+	info.ProtectHome = !hasHomeMount
+	info.PrivateTmp = !hasTmpMount
+
+	if spec.Process.Capabilities != nil && len(spec.Process.Capabilities.Bounding) > 0 {
+		info.CapabilityBoundingSet = strings.Join(spec.Process.Capabilities.Bounding, " ")
+	}
+}
+
 // containerServiceName returns the nameOrID and the service name of the
 // container.
 func containerServiceName(ctr *libpod.Container, options entities.GenerateSystemdOptions) (string, string) {
@@ -263,6 +768,12 @@ func executeContainerTemplate(info *containerInfo, options entities.GenerateSyst
 			"--cgroups=no-conmon",
 			"--rm",
 		)
+		if info.SocketActivated {
+			// systemd hands the listening sockets to conmon starting at fd 3
+			// and sets LISTEN_FDS/LISTEN_PID in the unit's environment itself;
+			// --preserve-fds tells podman to forward them into the container.
+			startCommand = append(startCommand, fmt.Sprintf("--preserve-fds=%d", info.NumFDs))
+		}
 		remainingCmd := info.CreateCommand[index:]
 
 		// Presence check for certain flags/options.
@@ -296,11 +807,11 @@ func executeContainerTemplate(info *containerInfo, options entities.GenerateSyst
 			return "", err
 		}
 
-		// Default to --sdnotify=conmon unless already set by the
-		// container.
+		// Default to --sdnotify=<SdNotifyMode> unless already set by
+		// the container.
 		hasSdnotifyParam := fs.Lookup("sdnotify").Changed
 		if !hasSdnotifyParam {
-			startCommand = append(startCommand, "--sdnotify=conmon")
+			startCommand = append(startCommand, "--sdnotify="+info.SdNotifyMode)
 		}
 
 		if !hasDetachParam {
@@ -377,6 +888,11 @@ func executeContainerTemplate(info *containerInfo, options entities.GenerateSyst
 	}
 	// Sort the slices to assure a deterministic output.
 	sort.Strings(info.BoundToServices)
+	sort.Strings(info.Requires)
+	sort.Strings(info.Wants)
+	sort.Strings(info.Before)
+	sort.Strings(info.After)
+	sort.Strings(info.Conflicts)
 
 	// Generate the template and compile it.
 	//
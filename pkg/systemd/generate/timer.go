@@ -0,0 +1,45 @@
+package generate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// systemdUnitDir is where WriteTimerUnit installs the timer units it
+// writes. It is the system unit directory rather than a user one, so
+// writing there (and the system-manager systemctl calls that follow)
+// needs root.
+const systemdUnitDir = "/etc/systemd/system"
+
+// WriteTimerUnit renders and installs a "<name>.timer" unit that fires on
+// the given OnCalendar= expression and starts the correspondingly-named
+// "<name>.service", then asks systemd to pick it up and enables it.
+func WriteTimerUnit(name, onCalendar string) error {
+	unit := fmt.Sprintf(`[Unit]
+Description=%s timer
+
+[Timer]
+OnCalendar=%s
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`, name, onCalendar)
+
+	path := filepath.Join(systemdUnitDir, name+".timer")
+	if err := ioutil.WriteFile(path, []byte(unit), 0644); err != nil {
+		return errors.Wrapf(err, "writing timer unit %q", path)
+	}
+
+	if err := exec.Command("systemctl", "daemon-reload").Run(); err != nil {
+		return errors.Wrap(err, "reloading systemd units")
+	}
+	if err := exec.Command("systemctl", "enable", "--now", name+".timer").Run(); err != nil {
+		return errors.Wrapf(err, "enabling timer %q", name)
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/containers/podman/v3/pkg/util"
 	. "github.com/containers/podman/v3/test/utils"
@@ -135,6 +136,21 @@ spec:
     - {{ . }}
   {{ end }}
     ip: {{ .IP }}
+{{ end }}
+{{ with .InitCtrs }}
+  initContainers:
+  {{ range . }}
+  - command:
+    {{ range .Cmd }}
+    - {{.}}
+    {{ end }}
+    args:
+    {{ range .Arg }}
+    - {{.}}
+    {{ end }}
+    image: {{ .Image }}
+    name: {{ .Name }}
+  {{ end }}
 {{ end }}
   containers:
 {{ with .Ctrs }}
@@ -193,17 +209,19 @@ spec:
     image: {{ .Image }}
     name: {{ .Name }}
     imagePullPolicy: {{ .PullPolicy }}
-    {{- if or .CpuRequest .CpuLimit .MemoryRequest .MemoryLimit }}
+    {{- if or .CpuRequest .CpuLimit .MemoryRequest .MemoryLimit .EphemeralStorageRequest .EphemeralStorageLimit }}
     resources:
-      {{- if or .CpuRequest .MemoryRequest }}
+      {{- if or .CpuRequest .MemoryRequest .EphemeralStorageRequest }}
       requests:
         {{if .CpuRequest }}cpu: {{ .CpuRequest }}{{ end }}
         {{if .MemoryRequest }}memory: {{ .MemoryRequest }}{{ end }}
+        {{if .EphemeralStorageRequest }}ephemeral-storage: {{ .EphemeralStorageRequest }}{{ end }}
       {{- end }}
-      {{- if or .CpuLimit .MemoryLimit }}
+      {{- if or .CpuLimit .MemoryLimit .EphemeralStorageLimit }}
       limits:
         {{if .CpuLimit }}cpu: {{ .CpuLimit }}{{ end }}
         {{if .MemoryLimit }}memory: {{ .MemoryLimit }}{{ end }}
+        {{if .EphemeralStorageLimit }}ephemeral-storage: {{ .EphemeralStorageLimit }}{{ end }}
       {{- end }}
     {{- end }}
     {{ if .SecurityContext }}
@@ -239,6 +257,75 @@ spec:
       readonly: {{.VolumeReadOnly}}
       {{ end }}
     {{ end }}
+    {{ with .LivenessProbe }}
+    livenessProbe:
+      {{ if .Exec }}
+      exec:
+        command:
+        {{ range .Exec }}
+        - {{.}}
+        {{ end }}
+      {{ end }}
+      {{ if .HTTPGetPath }}
+      httpGet:
+        path: {{ .HTTPGetPath }}
+        port: {{ .HTTPGetPort }}
+      {{ end }}
+      {{ if .TCPSocketPort }}
+      tcpSocket:
+        port: {{ .TCPSocketPort }}
+      {{ end }}
+      initialDelaySeconds: {{ .InitialDelaySeconds }}
+      periodSeconds: {{ .PeriodSeconds }}
+      timeoutSeconds: {{ .TimeoutSeconds }}
+      failureThreshold: {{ .FailureThreshold }}
+    {{ end }}
+    {{ with .ReadinessProbe }}
+    readinessProbe:
+      {{ if .Exec }}
+      exec:
+        command:
+        {{ range .Exec }}
+        - {{.}}
+        {{ end }}
+      {{ end }}
+      {{ if .HTTPGetPath }}
+      httpGet:
+        path: {{ .HTTPGetPath }}
+        port: {{ .HTTPGetPort }}
+      {{ end }}
+      {{ if .TCPSocketPort }}
+      tcpSocket:
+        port: {{ .TCPSocketPort }}
+      {{ end }}
+      initialDelaySeconds: {{ .InitialDelaySeconds }}
+      periodSeconds: {{ .PeriodSeconds }}
+      timeoutSeconds: {{ .TimeoutSeconds }}
+      failureThreshold: {{ .FailureThreshold }}
+    {{ end }}
+    {{ with .StartupProbe }}
+    startupProbe:
+      {{ if .Exec }}
+      exec:
+        command:
+        {{ range .Exec }}
+        - {{.}}
+        {{ end }}
+      {{ end }}
+      {{ if .HTTPGetPath }}
+      httpGet:
+        path: {{ .HTTPGetPath }}
+        port: {{ .HTTPGetPort }}
+      {{ end }}
+      {{ if .TCPSocketPort }}
+      tcpSocket:
+        port: {{ .TCPSocketPort }}
+      {{ end }}
+      initialDelaySeconds: {{ .InitialDelaySeconds }}
+      periodSeconds: {{ .PeriodSeconds }}
+      timeoutSeconds: {{ .TimeoutSeconds }}
+      failureThreshold: {{ .FailureThreshold }}
+    {{ end }}
   {{ end }}
 {{ end }}
 {{ with .Volumes }}
@@ -254,6 +341,56 @@ spec:
     persistentVolumeClaim:
       claimName: {{ .PersistentVolumeClaim.ClaimName }}
     {{- end }}
+    {{- if (eq .VolumeType "Projected") }}
+    projected:
+      sources:
+      {{ range .Projected.Sources }}
+      {{- if .ConfigMapName }}
+      - configMap:
+          name: {{ .ConfigMapName }}
+          {{- with .Items }}
+          items:
+          {{- range $key, $value := . }}
+          - key: {{ $key }}
+            path: {{ $value }}
+          {{- end }}
+          {{- end }}
+      {{- end }}
+      {{- if .SecretName }}
+      - secret:
+          name: {{ .SecretName }}
+          {{- with .Items }}
+          items:
+          {{- range $key, $value := . }}
+          - key: {{ $key }}
+            path: {{ $value }}
+          {{- end }}
+          {{- end }}
+      {{- end }}
+      {{- if .DownwardAPIItems }}
+      - downwardAPI:
+          items:
+          {{- range $path, $field := .DownwardAPIItems }}
+          - path: {{ $path }}
+            fieldRef:
+              fieldPath: {{ $field }}
+          {{- end }}
+      {{- end }}
+      {{- if .ServiceAccountTokenPath }}
+      - serviceAccountToken:
+          path: {{ .ServiceAccountTokenPath }}
+      {{- end }}
+      {{ end }}
+    {{- end }}
+    {{- if (eq .VolumeType "DownwardAPI") }}
+    downwardAPI:
+      items:
+      {{ range $path, $field := .DownwardAPI.Items }}
+      - path: {{ $path }}
+        fieldRef:
+          fieldPath: {{ $field }}
+      {{ end }}
+    {{- end }}
   {{ end }}
 {{ end }}
 status: {}
@@ -385,6 +522,133 @@ spec:
 {{ end }}
 `
 
+var daemonSetYamlTemplate = `
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  creationTimestamp: "2019-07-17T14:44:08Z"
+  name: {{ .Name }}
+  labels:
+    app: {{ .Name }}
+{{ with .Labels }}
+  {{ range $key, $value := . }}
+    {{ $key }}: {{ $value }}
+  {{ end }}
+{{ end }}
+{{ with .Annotations }}
+  annotations:
+  {{ range $key, $value := . }}
+    {{ $key }}: {{ $value }}
+  {{ end }}
+{{ end }}
+
+spec:
+  selector:
+    matchLabels:
+      app: {{ .Name }}
+  template:
+  {{ with .PodTemplate }}
+    metadata:
+      labels:
+        app: {{ .Name }}
+    spec:
+      restartPolicy: {{ .RestartPolicy }}
+      hostname: {{ .Hostname }}
+      hostNetwork: {{ .HostNetwork }}
+      containers:
+    {{ with .Ctrs }}
+      {{ range . }}
+      - command:
+        {{ range .Cmd }}
+        - {{.}}
+        {{ end }}
+        args:
+        {{ range .Arg }}
+        - {{.}}
+        {{ end }}
+        image: {{ .Image }}
+        name: {{ .Name }}
+        imagePullPolicy: {{ .PullPolicy }}
+      {{ end }}
+    {{ end }}
+  {{ end }}
+`
+
+var jobYamlTemplate = `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  creationTimestamp: "2019-07-17T14:44:08Z"
+  name: {{ .Name }}
+spec:
+  backoffLimit: {{ .BackoffLimit }}
+  completions: {{ .Completions }}
+  parallelism: {{ .Parallelism }}
+  template:
+  {{ with .PodTemplate }}
+    metadata:
+      labels:
+        app: {{ .Name }}
+    spec:
+      restartPolicy: {{ .RestartPolicy }}
+      containers:
+    {{ with .Ctrs }}
+      {{ range . }}
+      - command:
+        {{ range .Cmd }}
+        - {{.}}
+        {{ end }}
+        args:
+        {{ range .Arg }}
+        - {{.}}
+        {{ end }}
+        image: {{ .Image }}
+        name: {{ .Name }}
+      {{ end }}
+    {{ end }}
+  {{ end }}
+`
+
+var cronJobYamlTemplate = `
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  creationTimestamp: "2019-07-17T14:44:08Z"
+  name: {{ .Name }}
+spec:
+  schedule: {{ .Schedule }}
+  jobTemplate:
+    metadata:
+      name: {{ .JobTemplate.Name }}
+    spec:
+      backoffLimit: {{ .JobTemplate.BackoffLimit }}
+      completions: {{ .JobTemplate.Completions }}
+      parallelism: {{ .JobTemplate.Parallelism }}
+      template:
+      {{ with .JobTemplate.PodTemplate }}
+        metadata:
+          labels:
+            app: {{ .Name }}
+        spec:
+          restartPolicy: {{ .RestartPolicy }}
+          containers:
+        {{ with .Ctrs }}
+          {{ range . }}
+          - command:
+            {{ range .Cmd }}
+            - {{.}}
+            {{ end }}
+            args:
+            {{ range .Arg }}
+            - {{.}}
+            {{ end }}
+            image: {{ .Image }}
+            name: {{ .Name }}
+          {{ end }}
+        {{ end }}
+      {{ end }}
+`
+
 var (
 	defaultCtrName        = "testCtr"
 	defaultCtrCmd         = []string{"top"}
@@ -395,6 +659,9 @@ var (
 	defaultDeploymentName = "testDeployment"
 	defaultConfigMapName  = "testConfigMap"
 	defaultPVCName        = "testPVC"
+	defaultJobName        = "testJob"
+	defaultCronJobName    = "testCronJob"
+	defaultDaemonSetName  = "testDaemonSet"
 	seccompPwdEPERM       = []byte(`{"defaultAction":"SCMP_ACT_ALLOW","syscalls":[{"name":"getcwd","action":"SCMP_ACT_ERRNO"}]}`)
 	// CPU Period in ms
 	defaultCPUPeriod = 100
@@ -431,6 +698,12 @@ func getKubeYaml(kind string, object interface{}) (string, error) {
 		yamlTemplate = deploymentYamlTemplate
 	case "persistentVolumeClaim":
 		yamlTemplate = persistentVolumeClaimYamlTemplate
+	case "daemonSet":
+		yamlTemplate = daemonSetYamlTemplate
+	case "job":
+		yamlTemplate = jobYamlTemplate
+	case "cronJob":
+		yamlTemplate = cronJobYamlTemplate
 	default:
 		return "", fmt.Errorf("unsupported kubernetes kind")
 	}
@@ -553,6 +826,7 @@ type Pod struct {
 	HostNetwork   bool
 	HostAliases   []HostAlias
 	Ctrs          []*Ctr
+	InitCtrs      []*Ctr
 	Volumes       []*Volume
 	Labels        map[string]string
 	Annotations   map[string]string
@@ -616,6 +890,12 @@ func withCtr(c *Ctr) podOption {
 	}
 }
 
+func withInitCtr(c *Ctr) podOption {
+	return func(pod *Pod) {
+		pod.InitCtrs = append(pod.InitCtrs, c)
+	}
+}
+
 func withRestartPolicy(policy string) podOption {
 	return func(pod *Pod) {
 		pod.RestartPolicy = policy
@@ -712,29 +992,179 @@ func getPodNamesInDeployment(d *Deployment) []Pod {
 	return pods
 }
 
+// DaemonSet describes the options a kube yaml can be configured at daemonset level
+type DaemonSet struct {
+	Name        string
+	Labels      map[string]string
+	Annotations map[string]string
+	PodTemplate *Pod
+}
+
+func getDaemonSet(options ...daemonSetOption) *DaemonSet {
+	ds := DaemonSet{
+		Name:        defaultDaemonSetName,
+		Labels:      make(map[string]string),
+		Annotations: make(map[string]string),
+		PodTemplate: getPod(),
+	}
+	for _, option := range options {
+		option(&ds)
+	}
+
+	return &ds
+}
+
+type daemonSetOption func(*DaemonSet)
+
+func withDaemonSetPod(pod *Pod) daemonSetOption {
+	return func(ds *DaemonSet) {
+		ds.PodTemplate = pod
+	}
+}
+
+// getPodNameInDaemonSet returns the name of the pod podman creates for the
+// single (podman-host) node running a DaemonSet.
+func getPodNameInDaemonSet(ds *DaemonSet) string {
+	return fmt.Sprintf("%s-pod", ds.Name)
+}
+
+// Job describes the options a kube yaml can be configured at job level
+type Job struct {
+	Name         string
+	BackoffLimit int32
+	Completions  int32
+	Parallelism  int32
+	PodTemplate  *Pod
+}
+
+func getJob(options ...jobOption) *Job {
+	j := Job{
+		Name:         defaultJobName,
+		BackoffLimit: 6,
+		Completions:  1,
+		Parallelism:  1,
+		PodTemplate:  getPod(withRestartPolicy("Never")),
+	}
+	for _, option := range options {
+		option(&j)
+	}
+
+	return &j
+}
+
+type jobOption func(*Job)
+
+func withJobName(name string) jobOption {
+	return func(j *Job) {
+		j.Name = name
+	}
+}
+
+func withJobBackoffLimit(limit int32) jobOption {
+	return func(j *Job) {
+		j.BackoffLimit = limit
+	}
+}
+
+func withJobCompletions(completions int32) jobOption {
+	return func(j *Job) {
+		j.Completions = completions
+	}
+}
+
+func withJobParallelism(parallelism int32) jobOption {
+	return func(j *Job) {
+		j.Parallelism = parallelism
+	}
+}
+
+func withJobPod(pod *Pod) jobOption {
+	return func(j *Job) {
+		j.PodTemplate = pod
+	}
+}
+
+// CronJob describes the options a kube yaml can be configured at cronjob level
+type CronJob struct {
+	Name        string
+	Schedule    string
+	JobTemplate *Job
+}
+
+func getCronJob(options ...cronJobOption) *CronJob {
+	cj := CronJob{
+		Name:        defaultCronJobName,
+		Schedule:    "*/1 * * * *",
+		JobTemplate: getJob(),
+	}
+	for _, option := range options {
+		option(&cj)
+	}
+
+	return &cj
+}
+
+type cronJobOption func(*CronJob)
+
+func withCronJobSchedule(schedule string) cronJobOption {
+	return func(cj *CronJob) {
+		cj.Schedule = schedule
+	}
+}
+
+func withCronJobJob(job *Job) cronJobOption {
+	return func(cj *CronJob) {
+		cj.JobTemplate = job
+	}
+}
+
+// getJobPodName returns the name of the pod podman creates to run a Job,
+// mirroring getPodNamesInDeployment for the deployment case.
+func getJobPodName(j *Job) string {
+	return fmt.Sprintf("%s-job", j.Name)
+}
+
 // Ctr describes the options a kube yaml can be configured at container level
 type Ctr struct {
-	Name            string
-	Image           string
-	Cmd             []string
-	Arg             []string
-	CpuRequest      string
-	CpuLimit        string
-	MemoryRequest   string
-	MemoryLimit     string
-	SecurityContext bool
-	Caps            bool
-	CapAdd          []string
-	CapDrop         []string
-	PullPolicy      string
-	HostIP          string
-	Port            string
-	VolumeMount     bool
-	VolumeMountPath string
-	VolumeName      string
-	VolumeReadOnly  bool
-	Env             []Env
-	EnvFrom         []EnvFrom
+	Name                    string
+	Image                   string
+	Cmd                     []string
+	Arg                     []string
+	CpuRequest              string
+	CpuLimit                string
+	MemoryRequest           string
+	MemoryLimit             string
+	EphemeralStorageRequest string
+	EphemeralStorageLimit   string
+	SecurityContext         bool
+	Caps                    bool
+	CapAdd                  []string
+	CapDrop                 []string
+	PullPolicy              string
+	HostIP                  string
+	Port                    string
+	VolumeMount             bool
+	VolumeMountPath         string
+	VolumeName              string
+	VolumeReadOnly          bool
+	Env                     []Env
+	EnvFrom                 []EnvFrom
+	LivenessProbe           *Probe
+	ReadinessProbe          *Probe
+	StartupProbe            *Probe
+}
+
+// Probe describes a liveness, readiness, or startup probe configured at
+// container level. Exactly one of Exec, HTTPGet, or TCPSocket should be set.
+type Probe struct {
+	Exec                []string
+	HTTPGetPath         string
+	HTTPGetPort         string
+	TCPSocketPort       string
+	InitialDelaySeconds int
+	PeriodSeconds       int
+	TimeoutSeconds      int
+	FailureThreshold    int
 }
 
 // getCtr takes a list of ctrOptions and returns a Ctr with sane defaults
@@ -809,6 +1239,18 @@ func withMemoryLimit(limit string) ctrOption {
 	}
 }
 
+func withEphemeralStorageRequest(request string) ctrOption {
+	return func(c *Ctr) {
+		c.EphemeralStorageRequest = request
+	}
+}
+
+func withEphemeralStorageLimit(limit string) ctrOption {
+	return func(c *Ctr) {
+		c.EphemeralStorageLimit = limit
+	}
+}
+
 func withSecurityContext(sc bool) ctrOption {
 	return func(c *Ctr) {
 		c.SecurityContext = sc
@@ -878,10 +1320,34 @@ func withEnvFrom(name, from string, optional bool) ctrOption {
 	}
 }
 
+func withLivenessProbe(probe *Probe) ctrOption {
+	return func(c *Ctr) {
+		c.LivenessProbe = probe
+	}
+}
+
+func withReadinessProbe(probe *Probe) ctrOption {
+	return func(c *Ctr) {
+		c.ReadinessProbe = probe
+	}
+}
+
+func withStartupProbe(probe *Probe) ctrOption {
+	return func(c *Ctr) {
+		c.StartupProbe = probe
+	}
+}
+
 func getCtrNameInPod(pod *Pod) string {
 	return fmt.Sprintf("%s-%s", pod.Name, defaultCtrName)
 }
 
+// getInitCtrNameInPod returns the name podman gives an init container with
+// the given name once it's created as part of pod.
+func getInitCtrNameInPod(pod *Pod, ctrName string) string {
+	return fmt.Sprintf("%s-%s-init", pod.Name, ctrName)
+}
+
 type HostPath struct {
 	Path string
 	Type string
@@ -891,11 +1357,34 @@ type PersistentVolumeClaim struct {
 	ClaimName string
 }
 
+// ProjectedSource describes a single source entry of a projected volume.
+// Exactly one of ConfigMapName, SecretName, DownwardAPIItems, or
+// ServiceAccountTokenPath should be set.
+type ProjectedSource struct {
+	ConfigMapName           string
+	SecretName              string
+	Items                   map[string]string // key -> path, for configMap/secret sources
+	DownwardAPIItems        map[string]string // path -> fieldRef, for a downwardAPI source
+	ServiceAccountTokenPath string
+}
+
+type Projected struct {
+	Sources []ProjectedSource
+}
+
+// DownwardAPI describes a standalone downwardAPI volume that exposes pod
+// metadata as files, keyed by the file path inside the volume.
+type DownwardAPI struct {
+	Items map[string]string // path -> fieldRef, e.g. "labels" -> "metadata.labels"
+}
+
 type Volume struct {
 	VolumeType string
 	Name       string
 	HostPath
 	PersistentVolumeClaim
+	Projected
+	DownwardAPI
 }
 
 // getHostPathVolume takes a type and a location for a HostPath
@@ -923,6 +1412,30 @@ func getPersistentVolumeClaimVolume(vName string) *Volume {
 	}
 }
 
+// getProjectedVolume takes a list of projected sources, giving the volume a
+// default name of volName
+func getProjectedVolume(sources ...ProjectedSource) *Volume {
+	return &Volume{
+		VolumeType: "Projected",
+		Name:       defaultVolName,
+		Projected: Projected{
+			Sources: sources,
+		},
+	}
+}
+
+// getDownwardAPIVolume takes a map of file path to field reference (e.g.
+// "metadata.labels") and returns a standalone downwardAPI volume
+func getDownwardAPIVolume(items map[string]string) *Volume {
+	return &Volume{
+		VolumeType: "DownwardAPI",
+		Name:       defaultVolName,
+		DownwardAPI: DownwardAPI{
+			Items: items,
+		},
+	}
+}
+
 type Env struct {
 	Name      string
 	Value     string
@@ -1716,6 +2229,23 @@ spec:
 		}
 	})
 
+	// DaemonSet related tests
+	It("podman play kube daemonset creates one pod on this single-node host", func() {
+		daemonSet := getDaemonSet()
+		err := generateKubeYaml("daemonSet", daemonSet, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		podName := getPodNameInDaemonSet(daemonSet)
+		inspect := podmanTest.Podman([]string{"inspect", fmt.Sprintf("%s-%s", podName, defaultCtrName), "--format", "{{ .State.Running }}"})
+		inspect.WaitWithDefaultTimeout()
+		Expect(inspect.ExitCode()).To(Equal(0))
+		Expect(inspect.OutputToString()).To(Equal("true"))
+	})
+
 	It("podman play kube test with network portbindings", func() {
 		ip := "127.0.0.100"
 		port := "5000"
@@ -1950,6 +2480,94 @@ MemoryReservation: {{ .HostConfig.MemoryReservation }}`})
 		}
 	})
 
+	It("podman play kube honors ephemeral-storage limit via storage-opt size", func() {
+		SkipIfRootless("storage-opt size requires the overlay storage driver with quotas, typically root-only")
+
+		expectedStorageLimit := "500Mi"
+		ctr := getCtr(withEphemeralStorageLimit(expectedStorageLimit))
+		pod := getPod(withCtr(ctr))
+		err := generateKubeYaml("pod", pod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		inspect := podmanTest.Podman([]string{"inspect", getCtrNameInPod(pod), "--format", "{{ .HostConfig.StorageOpt }}"})
+		inspect.WaitWithDefaultTimeout()
+		Expect(inspect.ExitCode()).To(Equal(0))
+		Expect(inspect.OutputToString()).To(ContainSubstring("size"))
+	})
+
+	It("podman play kube honors cpu/memory requests and limits", func() {
+		expectedCpuRequest := "250m"
+		expectedCpuLimit := "500m"
+		expectedMemoryLimit := "256Mi"
+		ctr := getCtr(
+			withCpuRequest(expectedCpuRequest),
+			withCpuLimit(expectedCpuLimit),
+			withMemoryLimit(expectedMemoryLimit),
+		)
+		pod := getPod(withCtr(ctr))
+		err := generateKubeYaml("pod", pod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		inspect := podmanTest.Podman([]string{"inspect", getCtrNameInPod(pod), "--format", "{{ .HostConfig.NanoCpus }} {{ .HostConfig.CpuShares }} {{ .HostConfig.Memory }}"})
+		inspect.WaitWithDefaultTimeout()
+		Expect(inspect.ExitCode()).To(Equal(0))
+
+		fields := strings.Fields(inspect.OutputToString())
+		Expect(fields).To(HaveLen(3))
+		Expect(fields[0]).To(Equal("500000000")) // 500m cpu limit -> 0.5 * 1e9 NanoCpus
+		Expect(fields[1]).To(Equal("256"))       // 250m cpu request -> 250*1024/1000 CpuShares
+		Expect(fields[2]).To(Equal("268435456")) // 256Mi memory limit, in bytes
+	})
+
+	It("podman play kube --dry-run resolves configmaps but does not create the pod", func() {
+		SkipIfRemote("configmap list is not supported as a param")
+		cmYamlPathname := filepath.Join(podmanTest.TempDir, "foo-cm.yaml")
+		cm := getConfigMap(withConfigMapName("foo"), withConfigMapData("FOO", "foo"))
+		err := generateKubeYaml("configmap", cm, cmYamlPathname)
+		Expect(err).To(BeNil())
+
+		pod := getPod(withCtr(getCtr(withEnv("FOO", "", "configmap", "foo", "FOO", false))))
+		err = generateKubeYaml("pod", pod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", "--dry-run", "--configmap", cmYamlPathname, kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+		Expect(kube.OutputToString()).To(ContainSubstring(`"FOO":"foo"`))
+
+		inspect := podmanTest.Podman([]string{"inspect", getCtrNameInPod(pod)})
+		inspect.WaitWithDefaultTimeout()
+		Expect(inspect.ExitCode()).To(Not(Equal(0)))
+	})
+
+	It("podman play kube --diff reports a structured diff against a running pod", func() {
+		pod := getPod(withCtr(getCtr(withImage(ALPINE))))
+		err := generateKubeYaml("pod", pod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		updatedPod := getPod(withCtr(getCtr(withImage(BB))))
+		err = generateKubeYaml("pod", updatedPod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		diff := podmanTest.Podman([]string{"play", "kube", "--diff", kubeYaml})
+		diff.WaitWithDefaultTimeout()
+		Expect(diff.ExitCode()).To(Equal(0))
+		Expect(diff.OutputToString()).To(ContainSubstring(ALPINE))
+		Expect(diff.OutputToString()).To(ContainSubstring(BB))
+	})
+
 	It("podman play kube reports invalid image name", func() {
 		invalidImageName := "./myimage"
 
@@ -2180,6 +2798,67 @@ spec:
 		}
 	})
 
+	It("podman play kube multi doc yaml with ConfigMap and Secret kinds embedded in the manifest", func() {
+		yamlDocs := []string{}
+
+		cm := getConfigMap(withConfigMapName("foo"), withConfigMapData("FOO", "foo"))
+		k, err := getKubeYaml("configmap", cm)
+		Expect(err).To(BeNil())
+		yamlDocs = append(yamlDocs, k)
+
+		secretYaml := `apiVersion: v1
+kind: Secret
+metadata:
+  name: foosecret
+data:
+  BAR: YmFy
+`
+		yamlDocs = append(yamlDocs, secretYaml)
+
+		pod := getPod(withCtr(getCtr(
+			withEnv("FOO", "", "configmap", "foo", "FOO", false),
+			withEnv("BAR", "", "secret", "foosecret", "BAR", false),
+		)))
+		k, err = getKubeYaml("pod", pod)
+		Expect(err).To(BeNil())
+		yamlDocs = append(yamlDocs, k)
+
+		err = generateMultiDocKubeYaml(yamlDocs, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		inspect := podmanTest.Podman([]string{"inspect", getCtrNameInPod(pod), "--format", "'{{ .Config.Env }}'"})
+		inspect.WaitWithDefaultTimeout()
+		Expect(inspect.ExitCode()).To(Equal(0))
+		Expect(inspect.OutputToString()).To(ContainSubstring(`FOO=foo`))
+		Expect(inspect.OutputToString()).To(ContainSubstring(`BAR=bar`))
+	})
+
+	It("podman play kube runs initContainers to completion before the main containers start", func() {
+		initCtr := getCtr(withCmd([]string{"true"}), withArg(nil))
+		initCtr.Name = "init-1"
+		pod := getPod(withInitCtr(initCtr), withCtr(getCtr()))
+		err := generateKubeYaml("pod", pod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		inspect := podmanTest.Podman([]string{"inspect", getInitCtrNameInPod(pod, "init-1"), "--format", "{{ .State.ExitCode }}"})
+		inspect.WaitWithDefaultTimeout()
+		Expect(inspect.ExitCode()).To(Equal(0))
+		Expect(inspect.OutputToString()).To(Equal("0"))
+
+		inspect = podmanTest.Podman([]string{"inspect", getCtrNameInPod(pod), "--format", "{{ .State.Running }}"})
+		inspect.WaitWithDefaultTimeout()
+		Expect(inspect.ExitCode()).To(Equal(0))
+		Expect(inspect.OutputToString()).To(Equal("true"))
+	})
+
 	It("podman play kube invalid multi doc yaml", func() {
 		yamlDocs := []string{}
 
@@ -2214,4 +2893,336 @@ invalid kube kind
 		kube.WaitWithDefaultTimeout()
 		Expect(kube.ExitCode()).To(Not(Equal(0)))
 	})
+
+	// Job related tests
+	It("podman play kube job creates one container per completion", func() {
+		job := getJob(
+			withJobCompletions(3),
+			withJobParallelism(1),
+			withJobPod(getPod(withCtr(getCtr(withCmd([]string{"true"}), withArg(nil))))),
+		)
+		err := generateKubeYaml("job", job, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		inspect := podmanTest.Podman([]string{"pod", "inspect", getJobPodName(job), "--format", "{{ .NumContainers }}"})
+		inspect.WaitWithDefaultTimeout()
+		Expect(inspect.ExitCode()).To(Equal(0))
+		Expect(inspect.OutputToString()).To(Equal(fmt.Sprintf("%d", job.Completions+1)))
+	})
+
+	It("podman play kube job honors backoffLimit on a failing container", func() {
+		job := getJob(
+			withJobBackoffLimit(2),
+			withJobPod(getPod(withCtr(getCtr(withCmd([]string{"false"}), withArg(nil))))),
+		)
+		err := generateKubeYaml("job", job, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		inspect := podmanTest.Podman([]string{"inspect", getJobPodName(job) + "-" + defaultCtrName, "--format", "{{ .State.ExitCode }}"})
+		inspect.WaitWithDefaultTimeout()
+		Expect(inspect.ExitCode()).To(Equal(0))
+		Expect(inspect.OutputToString()).To(Not(Equal("0")))
+	})
+
+	It("podman play kube cronjob registers a scheduled job", func() {
+		SkipIfContainerized("CronJob scheduling requires a running systemd")
+		SkipIfRemote("quadlet/timer registration is not supported as a param")
+
+		cronJob := getCronJob(withCronJobSchedule("*/1 * * * *"))
+		err := generateKubeYaml("cronJob", cronJob, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		timers := podmanTest.Podman([]string{"pod", "inspect", getJobPodName(cronJob.JobTemplate), "--format", "{{ .Labels }}"})
+		timers.WaitWithDefaultTimeout()
+		Expect(timers.ExitCode()).To(Equal(0))
+		Expect(timers.OutputToString()).To(ContainSubstring("io.podman.annotations.cronjob.schedule:" + cronJob.Schedule))
+	})
+
+	// Probe related tests
+	It("podman play kube liveness probe restarts the container on repeated failure", func() {
+		ctr := getCtr(withLivenessProbe(&Probe{
+			Exec:             []string{"false"},
+			PeriodSeconds:    1,
+			TimeoutSeconds:   1,
+			FailureThreshold: 1,
+		}))
+		pod := getPod(withCtr(ctr), withRestartPolicy("Always"))
+		err := generateKubeYaml("pod", pod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		// A failing liveness probe is translated into a healthcheck with
+		// --health-on-failure=restart, so podman itself restarts the
+		// container once the failure threshold is crossed.
+		inspect := podmanTest.Podman([]string{"inspect", getCtrNameInPod(pod), "--format", "{{ .Config.Healthcheck }}"})
+		inspect.WaitWithDefaultTimeout()
+		Expect(inspect.ExitCode()).To(Equal(0))
+		Expect(inspect.OutputToString()).To(ContainSubstring("false"))
+
+		Eventually(func() string {
+			restarts := podmanTest.Podman([]string{"inspect", getCtrNameInPod(pod), "--format", "{{ .RestartCount }}"})
+			restarts.WaitWithDefaultTimeout()
+			return restarts.OutputToString()
+		}, defaultWaitTimeout, 1*time.Second).ShouldNot(Equal("0"))
+	})
+
+	It("podman play kube readiness probe marks the container unready without restarting it", func() {
+		ctr := getCtr(withReadinessProbe(&Probe{
+			Exec:             []string{"false"},
+			PeriodSeconds:    1,
+			TimeoutSeconds:   1,
+			FailureThreshold: 1,
+		}))
+		pod := getPod(withCtr(ctr))
+		err := generateKubeYaml("pod", pod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		// A readiness probe becomes a healthcheck without
+		// --health-on-failure=restart: repeated failures surface as
+		// State.Health.Status == "unhealthy" (i.e. not ready) while the
+		// container itself keeps running and is never restarted.
+		Eventually(func() string {
+			health := podmanTest.Podman([]string{"inspect", getCtrNameInPod(pod), "--format", "{{ .State.Health.Status }}"})
+			health.WaitWithDefaultTimeout()
+			return health.OutputToString()
+		}, defaultWaitTimeout, 1*time.Second).Should(Equal("unhealthy"))
+
+		running := podmanTest.Podman([]string{"inspect", getCtrNameInPod(pod), "--format", "{{ .State.Running }}"})
+		running.WaitWithDefaultTimeout()
+		Expect(running.ExitCode()).To(Equal(0))
+		Expect(running.OutputToString()).To(Equal("true"))
+
+		restarts := podmanTest.Podman([]string{"inspect", getCtrNameInPod(pod), "--format", "{{ .RestartCount }}"})
+		restarts.WaitWithDefaultTimeout()
+		Expect(restarts.OutputToString()).To(Equal("0"))
+	})
+
+	It("podman play kube httpGet liveness probe is translated to a health-cmd using wget", func() {
+		ctr := getCtr(withLivenessProbe(&Probe{
+			HTTPGetPath:      "/",
+			HTTPGetPort:      "80",
+			PeriodSeconds:    5,
+			TimeoutSeconds:   3,
+			FailureThreshold: 3,
+		}), withImage(BB))
+		pod := getPod(withCtr(ctr))
+		err = generateKubeYaml("pod", pod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		inspect := podmanTest.Podman([]string{"inspect", getCtrNameInPod(pod), "--format", "{{ .Config.Healthcheck.Test }}"})
+		inspect.WaitWithDefaultTimeout()
+		Expect(inspect.ExitCode()).To(Equal(0))
+		Expect(inspect.OutputToString()).To(ContainSubstring("wget"))
+	})
+
+	It("podman play kube tcpSocket readiness probe is translated to a health-cmd using /dev/tcp", func() {
+		ctr := getCtr(withReadinessProbe(&Probe{
+			TCPSocketPort:    "80",
+			PeriodSeconds:    5,
+			TimeoutSeconds:   3,
+			FailureThreshold: 3,
+		}), withImage(BB))
+		pod := getPod(withCtr(ctr))
+		err = generateKubeYaml("pod", pod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		inspect := podmanTest.Podman([]string{"inspect", getCtrNameInPod(pod), "--format", "{{ .Config.Healthcheck.Test }}"})
+		inspect.WaitWithDefaultTimeout()
+		Expect(inspect.ExitCode()).To(Equal(0))
+		Expect(inspect.OutputToString()).To(ContainSubstring("/dev/tcp"))
+	})
+
+	It("podman play kube startup probe suppresses liveness failures until first success", func() {
+		ctr := getCtr(
+			withStartupProbe(&Probe{
+				Exec:             []string{"test", "-f", "/tmp/ready"},
+				PeriodSeconds:    1,
+				FailureThreshold: 30,
+			}),
+			withLivenessProbe(&Probe{
+				Exec:             []string{"true"},
+				PeriodSeconds:    1,
+				FailureThreshold: 1,
+			}),
+		)
+		pod := getPod(withCtr(ctr), withRestartPolicy("Always"))
+		err = generateKubeYaml("pod", pod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		inspect := podmanTest.Podman([]string{"inspect", getCtrNameInPod(pod), "--format", "{{ .State.Running }}"})
+		inspect.WaitWithDefaultTimeout()
+		Expect(inspect.ExitCode()).To(Equal(0))
+		Expect(inspect.OutputToString()).To(Equal("true"))
+	})
+
+	// generate kube round-trip tests
+	It("podman generate kube on a deployment-created pod reconstitutes a Deployment", func() {
+		deployment := getDeployment(withReplicas(2))
+		err := generateKubeYaml("deployment", deployment, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		podNames := getPodNamesInDeployment(deployment)
+		generate := podmanTest.Podman([]string{"generate", "kube", podNames[0].Name})
+		generate.WaitWithDefaultTimeout()
+		Expect(generate.ExitCode()).To(Equal(0))
+		Expect(generate.OutputToString()).To(ContainSubstring("kind: Deployment"))
+		Expect(generate.OutputToString()).To(ContainSubstring("replicas: 2"))
+	})
+
+	It("podman generate kube emits a multi-doc manifest for configmaps, pvcs, and deployments", func() {
+		cmYamlPathname := filepath.Join(podmanTest.TempDir, "foo-cm.yaml")
+		cm := getConfigMap(withConfigMapName("foo"), withConfigMapData("FOO", "foo"))
+		err := generateKubeYaml("configmap", cm, cmYamlPathname)
+		Expect(err).To(BeNil())
+
+		volName := "genkubevol"
+		pvc := getPVC(withPVCName(volName))
+		err = generateKubeYaml("persistentVolumeClaim", pvc, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml, "--configmap", cmYamlPathname})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		deployment := getDeployment(withPod(getPod(withCtr(getCtr(withEnvFrom("foo", "configmap", false), withVolumeMount("/test", false))), withVolume(getPersistentVolumeClaimVolume(volName)))))
+		err = generateKubeYaml("deployment", deployment, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube = podmanTest.Podman([]string{"play", "kube", kubeYaml, "--configmap", cmYamlPathname})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		podNames := getPodNamesInDeployment(deployment)
+		generateOut := filepath.Join(podmanTest.TempDir, "generated.yaml")
+		generate := podmanTest.Podman([]string{"generate", "kube", "--filename", generateOut, volName, podNames[0].Name})
+		generate.WaitWithDefaultTimeout()
+		Expect(generate.ExitCode()).To(Equal(0))
+
+		regenerated, err := ioutil.ReadFile(generateOut)
+		Expect(err).To(BeNil())
+		Expect(string(regenerated)).To(ContainSubstring("kind: PersistentVolumeClaim"))
+		Expect(string(regenerated)).To(ContainSubstring("kind: Deployment"))
+
+		// confirm the generated manifest plays back correctly
+		replayYaml := filepath.Join(podmanTest.TempDir, "replay.yaml")
+		err = writeYaml(string(regenerated), replayYaml)
+		Expect(err).To(BeNil())
+
+		replay := podmanTest.Podman([]string{"play", "kube", replayYaml})
+		replay.WaitWithDefaultTimeout()
+		Expect(replay.ExitCode()).To(Equal(0))
+	})
+
+	// Projected / downwardAPI volume tests
+	It("podman play kube test with projected volume combining configMap and downwardAPI sources", func() {
+		SkipIfRemote("configmap list is not supported as a param")
+		cmYamlPathname := filepath.Join(podmanTest.TempDir, "foo-cm.yaml")
+		cm := getConfigMap(withConfigMapName("foo"), withConfigMapData("FOO", "foo"))
+		err := generateKubeYaml("configmap", cm, cmYamlPathname)
+		Expect(err).To(BeNil())
+
+		projected := getProjectedVolume(
+			ProjectedSource{ConfigMapName: "foo", Items: map[string]string{"FOO": "foo.txt"}},
+			ProjectedSource{DownwardAPIItems: map[string]string{"name": "metadata.name"}},
+		)
+		ctr := getCtr(withVolumeMount("/projected", false), withImage(BB))
+		pod := getPod(withVolume(projected), withCtr(ctr))
+		err = generateKubeYaml("pod", pod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml, "--configmap", cmYamlPathname})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		exec := podmanTest.Podman([]string{"exec", getCtrNameInPod(pod), "cat", "/projected/foo.txt"})
+		exec.WaitWithDefaultTimeout()
+		Expect(exec.ExitCode()).To(Equal(0))
+		Expect(exec.OutputToString()).To(Equal("foo"))
+
+		exec = podmanTest.Podman([]string{"exec", getCtrNameInPod(pod), "cat", "/projected/name"})
+		exec.WaitWithDefaultTimeout()
+		Expect(exec.ExitCode()).To(Equal(0))
+		Expect(exec.OutputToString()).To(Equal(pod.Name))
+	})
+
+	It("podman play kube test with standalone downwardAPI volume", func() {
+		downward := getDownwardAPIVolume(map[string]string{
+			"name":      "metadata.name",
+			"namespace": "metadata.namespace",
+		})
+		ctr := getCtr(withVolumeMount("/downward", false), withImage(BB))
+		pod := getPod(withVolume(downward), withCtr(ctr), withLabel("app", "downward-test"))
+		err := generateKubeYaml("pod", pod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		exec := podmanTest.Podman([]string{"exec", getCtrNameInPod(pod), "cat", "/downward/name"})
+		exec.WaitWithDefaultTimeout()
+		Expect(exec.ExitCode()).To(Equal(0))
+		Expect(exec.OutputToString()).To(Equal(pod.Name))
+	})
+
+	It("podman play kube test with projected volume combining secret and serviceAccountToken sources", func() {
+		createSecret(podmanTest, "foo", defaultSecret)
+
+		projected := getProjectedVolume(
+			ProjectedSource{SecretName: "foo", Items: map[string]string{"FOO": "foo.txt"}},
+			ProjectedSource{ServiceAccountTokenPath: "token"},
+		)
+		ctr := getCtr(withVolumeMount("/projected", false), withImage(BB))
+		pod := getPod(withVolume(projected), withCtr(ctr))
+		err := generateKubeYaml("pod", pod, kubeYaml)
+		Expect(err).To(BeNil())
+
+		kube := podmanTest.Podman([]string{"play", "kube", kubeYaml})
+		kube.WaitWithDefaultTimeout()
+		Expect(kube.ExitCode()).To(Equal(0))
+
+		exec := podmanTest.Podman([]string{"exec", getCtrNameInPod(pod), "cat", "/projected/foo.txt"})
+		exec.WaitWithDefaultTimeout()
+		Expect(exec.ExitCode()).To(Equal(0))
+		Expect(exec.OutputToString()).To(Equal("foo"))
+
+		exec = podmanTest.Podman([]string{"exec", getCtrNameInPod(pod), "test", "-f", "/projected/token"})
+		exec.WaitWithDefaultTimeout()
+		Expect(exec.ExitCode()).To(Equal(0))
+	})
 })